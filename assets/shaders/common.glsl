@@ -0,0 +1,20 @@
+// Shared declarations for engine shaders: #include "common.glsl"
+#pragma once
+
+// Filled by the engine once per frame (core.FrameConstants).
+layout(std140) uniform Frame {
+    vec2  uResolution;
+    float uTime;
+    float uDeltaTime;
+    uint  uFrameIndex;
+};
+
+// View-projection of the current 2D scene (Renderer2D.BeginScene).
+layout(std140) uniform Camera2D {
+    mat4 uViewProj;
+};
+
+// Decodes sRGB-encoded rgb in [0,1] to linear.
+vec3 srgbToLinear(vec3 c) {
+    return mix(c / 12.92, pow((c + 0.055) / 1.055, vec3(2.4)), greaterThan(c, vec3(0.04045)));
+}
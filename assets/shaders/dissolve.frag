@@ -0,0 +1,29 @@
+#version 330 core
+
+// Renderer2D material: burns the sprite away where uNoise is below
+// uThreshold, with a glowing edge.
+
+in vec4 vColor;
+in vec2 vUV;
+flat in uint vTexIndex;
+
+out vec4 FragColor;
+
+#ifndef MAX_TEXTURES
+#define MAX_TEXTURES 16
+#endif
+uniform sampler2D uTex[MAX_TEXTURES];
+uniform sampler2D uNoise;
+uniform float uThreshold; // 0 => intact, 1 => gone
+uniform vec4 uEdgeColor;
+
+const float EDGE = 0.08;
+
+void main() {
+    vec4 color = texture(uTex[vTexIndex], vUV) * vColor;
+    float n = texture(uNoise, vUV).r;
+    if (n < uThreshold) discard;
+    float edge = 1.0 - smoothstep(0.0, EDGE, n - uThreshold);
+    color.rgb = mix(color.rgb, uEdgeColor.rgb, edge * step(0.001, uThreshold));
+    FragColor = color;
+}
@@ -0,0 +1,11 @@
+#version 330 core
+
+// One triangle covering the viewport: (-1,-1), (3,-1), (-1,3).
+layout(location=0) in vec2 aPos;
+
+out vec2 vUV;
+
+void main() {
+    vUV = aPos * 0.5 + 0.5;
+    gl_Position = vec4(aPos, 0.0, 1.0);
+}
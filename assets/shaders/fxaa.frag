@@ -0,0 +1,46 @@
+#version 330 core
+
+// Copies the scene texture, or filters it with FXAA when uFXAA is set
+// (the compact FXAA variant: one edge direction from the 4 diagonal
+// neighbours, blended along it).
+
+in vec2 vUV;
+out vec4 FragColor;
+
+uniform sampler2D uScene;
+uniform vec2 uTexelSize; // 1 / scene size
+uniform bool uFXAA;
+
+#define FXAA_REDUCE_MIN (1.0 / 128.0)
+#define FXAA_REDUCE_MUL (1.0 / 8.0)
+#define FXAA_SPAN_MAX   8.0
+
+float luma(vec3 c) { return dot(c, vec3(0.299, 0.587, 0.114)); }
+
+void main() {
+    vec4 center = texture(uScene, vUV);
+    if (!uFXAA) {
+        FragColor = center;
+        return;
+    }
+
+    float lNW = luma(texture(uScene, vUV + vec2(-1.0, -1.0) * uTexelSize).rgb);
+    float lNE = luma(texture(uScene, vUV + vec2( 1.0, -1.0) * uTexelSize).rgb);
+    float lSW = luma(texture(uScene, vUV + vec2(-1.0,  1.0) * uTexelSize).rgb);
+    float lSE = luma(texture(uScene, vUV + vec2( 1.0,  1.0) * uTexelSize).rgb);
+    float lM  = luma(center.rgb);
+    float lMin = min(lM, min(min(lNW, lNE), min(lSW, lSE)));
+    float lMax = max(lM, max(max(lNW, lNE), max(lSW, lSE)));
+
+    vec2 dir = vec2(-((lNW + lNE) - (lSW + lSE)), (lNW + lSW) - (lNE + lSE));
+    float reduce = max((lNW + lNE + lSW + lSE) * 0.25 * FXAA_REDUCE_MUL, FXAA_REDUCE_MIN);
+    float rcpMin = 1.0 / (min(abs(dir.x), abs(dir.y)) + reduce);
+    dir = clamp(dir * rcpMin, vec2(-FXAA_SPAN_MAX), vec2(FXAA_SPAN_MAX)) * uTexelSize;
+
+    vec3 a = 0.5 * (texture(uScene, vUV + dir * (1.0 / 3.0 - 0.5)).rgb +
+                    texture(uScene, vUV + dir * (2.0 / 3.0 - 0.5)).rgb);
+    vec3 b = a * 0.5 + 0.25 * (texture(uScene, vUV - dir * 0.5).rgb +
+                               texture(uScene, vUV + dir * 0.5).rgb);
+    float lB = luma(b);
+    FragColor = vec4((lB < lMin || lB > lMax) ? a : b, center.a);
+}
@@ -1,15 +1,20 @@
 #version 330 core
 in vec4 vColor;
 in vec2 vUV;
-in float vTexIndex;
+flat in uint vTexIndex;
 
 out vec4 FragColor;
 
-// Bind up to 16 texture slots as an array
-uniform sampler2D uTex[16];
+// Sized by Renderer2D from the device texture unit limit
+#ifndef MAX_TEXTURES
+#define MAX_TEXTURES 16
+#endif
+uniform sampler2D uTex[MAX_TEXTURES];
 
 void main() {
-    int idx = int(vTexIndex);
-    vec4 tex = texture(uTex[idx], vUV);
+    vec4 tex = texture(uTex[vTexIndex], vUV);
     FragColor = tex * vColor;
+#ifdef ALPHA_TEST
+    if (FragColor.a < 0.5) discard;
+#endif
 }
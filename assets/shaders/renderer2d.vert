@@ -1,18 +1,23 @@
 #version 330 core
+#include "common.glsl"
+
 layout(location=0) in vec2 aPos;
 layout(location=1) in vec4 aColor;
 layout(location=2) in vec2 aUV;
-layout(location=3) in float aTexIndex;
-
-uniform mat4 uVP;
+layout(location=3) in uint aTexIndex;
 
 out vec4 vColor;
 out vec2 vUV;
-out float vTexIndex;
+flat out uint vTexIndex;
 
 void main() {
+#ifdef LINEAR_COLOR
+    // packed as sRGB, which keeps 8-bit precision where the eye needs it
+    vColor = vec4(srgbToLinear(aColor.rgb), aColor.a);
+#else
     vColor = aColor;
+#endif
     vUV = aUV;
     vTexIndex = aTexIndex;
-    gl_Position = uVP * vec4(aPos, 0.0, 1.0);
+    gl_Position = uViewProj * vec4(aPos, 0.0, 1.0);
 }
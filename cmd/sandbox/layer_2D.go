@@ -1,12 +1,18 @@
 package main
 
 import (
+	"math"
+	"math/rand/v2"
+
+	"github.com/hubastard/grove/engine/anim"
 	"github.com/hubastard/grove/engine/assets"
 	"github.com/hubastard/grove/engine/colors"
 	"github.com/hubastard/grove/engine/core"
+	"github.com/hubastard/grove/engine/gfx/postfx"
 	"github.com/hubastard/grove/engine/gfx/renderer2d"
 	"github.com/hubastard/grove/engine/profiler"
 	"github.com/hubastard/grove/engine/scene"
+	"github.com/hubastard/grove/engine/tilemap"
 )
 
 // ------- A simple 2D Layer demo -------
@@ -14,9 +20,18 @@ type Layer2D struct {
 	cam    *scene.OrthoCamera2D
 	ctrl   *scene.OrthoController2D
 	r2d    *renderer2d.Renderer2D
-	tex    core.Texture
+	atlas  *assets.Atlas // streamed in; drawn once ready
 	player renderer2d.SubTexture2D
+	walk   *anim.Clip
+	anim   anim.Animator
 	t      float32
+	smooth core.Sampler // editor-style linear filtering, toggled with L
+	linear bool
+	post   *postfx.Pass // offscreen pass: F toggles FXAA, M toggles 4x MSAA
+	clear  colors.Color // of the offscreen target
+	burn   *renderer2d.Material
+	world  *tilemap.Map
+	spawn  [2]float32 // feet of the player, from the map's "spawn" object
 }
 
 func (l *Layer2D) OnAttach(e *core.Engine) {
@@ -26,26 +41,85 @@ func (l *Layer2D) OnAttach(e *core.Engine) {
 	l.cam.SetZoom(4)
 	l.ctrl = scene.NewOrthoController2D(l.cam)
 
-	w, h, pixels, err := assets.LoadPNG("player.png")
+	var err error
+	l.atlas, err = assets.LoadAtlas([]string{"player.png"}, assets.AtlasOptions{Padding: 2, Extrude: 1})
 	if err != nil {
 		panic(err)
 	}
-
-	l.tex, err = e.Renderer.CreateTexture(core.TextureDesc{
-		Width:     w,
-		Height:    h,
-		Format:    core.TextureRGBA8,
-		Pixels:    pixels,
+	err = l.atlas.Upload(e.Renderer, core.TextureDesc{
 		MinFilter: "linear",
 		MagFilter: "nearest",
 		WrapU:     "clamp",
 		WrapV:     "clamp",
+		Label:     "Layer2D.atlas",
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	reg := l.atlas.Regions["player.png"]
+	page := l.atlas.Pages[reg.Page]
+	sheet := anim.Grid{
+		Texture: l.atlas.Texture(reg.Page), Width: page.W, Height: page.H,
+		CellW: 32, CellH: 32, X: reg.X, Y: reg.Y, SheetW: reg.W,
+	}
+	l.player = sheet.Frame(0)
+	l.walk = sheet.Row("walk", anim.Loop, 0.1, 1, 0, 6)
+
+	l.smooth, err = e.Renderer.CreateSampler(core.SamplerDesc{
+		MinFilter: "linear",
+		MagFilter: "linear",
+		WrapU:     "clamp",
+		WrapV:     "clamp",
+		Label:     "Layer2D.smooth",
 	})
 	if err != nil {
 		panic(err)
 	}
 
-	l.player = renderer2d.FromPixels(l.tex, 0, 0, 32, 32, w, h)
+	l.createBurn(e)
+
+	l.world, err = tilemap.Load(e.Renderer, "demo.tmj")
+	if err != nil {
+		panic(err)
+	}
+	if sp := l.world.Object("spawn"); sp != nil {
+		l.spawn = [2]float32{sp.X, sp.Y}
+	}
+	l.cam.SetPosition(l.spawn[0], l.spawn[1]-16)
+}
+
+// createBurn builds the dissolve material with a random noise texture.
+func (l *Layer2D) createBurn(e *core.Engine) {
+	const n = 32
+	pix := make([]byte, n*n*4)
+	for i := 0; i < len(pix); i += 4 {
+		v := byte(rand.IntN(256))
+		pix[i], pix[i+1], pix[i+2], pix[i+3] = v, v, v, 255
+	}
+	noise, err := e.Renderer.CreateTexture(core.TextureDesc{
+		Width: n, Height: n,
+		Format:    core.TextureRGBA8,
+		Pixels:    pix,
+		MinFilter: "linear", MagFilter: "linear",
+		WrapU: "repeat", WrapV: "repeat",
+		NonColor: true,
+		Label:    "Layer2D.noise",
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	fs, err := assets.LoadShaderSource("dissolve.frag")
+	if err != nil {
+		panic(err)
+	}
+	l.burn, err = l.r2d.CreateMaterial(fs, "Layer2D.burn")
+	if err != nil {
+		panic(err)
+	}
+	l.burn.Textures = []core.TextureBinding{{Uniform: "uNoise", Texture: noise}}
+	l.burn.Uniforms["uEdgeColor"] = colors.Color{1, 0.5, 0.1, 1}
 }
 
 func (l *Layer2D) OnDetach(e *core.Engine) {}
@@ -53,6 +127,9 @@ func (l *Layer2D) OnDetach(e *core.Engine) {}
 func (l *Layer2D) OnUpdate(e *core.Engine, dt float64) {
 	l.ctrl.Update(e, float32(dt))
 	l.t += float32(dt)
+	l.anim.Play(l.walk)
+	l.anim.Update(float32(dt))
+	l.world.Update(float32(dt))
 
 	if e.Input.IsKeyDown(core.KeyEscape) {
 		e.Window.RequestClose()
@@ -62,17 +139,72 @@ func (l *Layer2D) OnUpdate(e *core.Engine, dt float64) {
 func (l *Layer2D) OnRender(e *core.Engine, alpha float64) {
 	scopeRender := profiler.Start("Layer2D.OnRender")
 
+	offscreen := l.post.FXAA || l.post.Samples > 0
+	if offscreen {
+		w, h := e.Window.FramebufferSize()
+		if err := l.post.Begin(w, h, l.clear); err != nil {
+			panic(err)
+		}
+	}
+
 	l.r2d.BeginScene(l.cam.VP())
+	l.r2d.SetSortMode(renderer2d.SortLayerDepth)
+	if l.linear {
+		l.r2d.SetSampler(l.smooth)
+	}
 	{
-		l.r2d.DrawSubTexQuad(0, 0, 32, 32, l.player, colors.White, l.t)
+		l.r2d.SetLayer(-2)
+		l.world.Draw(l.r2d, l.cam)
+		l.r2d.SetLayer(0)
+
+		// walks back and forth, facing where it goes, swaying about its feet
+		px, py := l.spawn[0]+16*float32(math.Sin(float64(l.t))), l.spawn[1]
+		if l.atlas.Ready() {
+			l.r2d.DrawSprite(renderer2d.Sprite{
+				X: px, Y: py, W: 32, H: 32,
+				Pivot:    renderer2d.PivotBottom,
+				Rotation: 0.15 * float32(math.Sin(float64(l.t*6))),
+				FlipX:    math.Cos(float64(l.t)) < 0,
+				Tint:     colors.White,
+				Sub:      l.anim.Sub(),
+			})
+
+			l.burn.Uniforms["uThreshold"] = float32(0.5 - 0.5*math.Cos(float64(l.t)))
+			l.r2d.PushMaterial(l.burn)
+			l.r2d.DrawSubTexQuad(l.spawn[0]+48, py-16, 32, 32, l.player, colors.White, 0)
+			l.r2d.PopMaterial()
+		}
+		// drawn after the player, but on the layer below
+		l.r2d.SetLayer(-1)
+		l.r2d.DrawQuad(px, py+2, 24, 6, colors.Black.WithAlpha(0.4), 0)
 	}
 	l.r2d.EndScene()
+	l.r2d.SetSortMode(renderer2d.SortNone)
+
+	if offscreen {
+		l.post.End()
+	}
 
 	scopeRender.End()
 }
 
 func (l *Layer2D) OnEvent(e *core.Engine, ev core.Event) bool {
 	switch v := ev.(type) {
+	case core.EventKey:
+		if !v.Down {
+			break
+		}
+		switch v.Key {
+		case core.KeyL:
+			l.linear = !l.linear
+			return true
+		case core.KeyF:
+			l.post.FXAA = !l.post.FXAA
+			return true
+		case core.KeyM:
+			l.post.Samples = 4 - l.post.Samples
+			return true
+		}
 	case core.EventResize:
 		l.cam.SetViewportPixels(v.W, v.H)
 	case core.EventScroll:
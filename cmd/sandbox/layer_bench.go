@@ -0,0 +1,120 @@
+package main
+
+import (
+	"log"
+	"math"
+	"time"
+
+	"github.com/hubastard/grove/engine/colors"
+	"github.com/hubastard/grove/engine/core"
+	"github.com/hubastard/grove/engine/gfx/renderer2d"
+	"github.com/hubastard/grove/engine/profiler"
+	"github.com/hubastard/grove/engine/scene"
+)
+
+// ------- Batch upload benchmark (-bench) -------
+//
+// Draws the same quads through one Renderer2D per mesh usage. Small batches
+// force hundreds of flushes per frame, so the cost is dominated by how mesh
+// updates synchronize with the GPU.
+
+const (
+	benchQuads       = 100_000
+	benchBatchQuads  = 256
+	benchFramesEach  = 300
+	benchWarmupFrame = 30
+)
+
+type benchMode struct {
+	name  string
+	usage core.MeshUsage
+	r2d   *renderer2d.Renderer2D
+
+	frames   int
+	cpu, gpu time.Duration
+	draws    int
+}
+
+type LayerBench struct {
+	vs, fs core.ShaderSource
+	cam    *scene.OrthoCamera2D
+	modes  []*benchMode
+	cur    int
+	frame  int
+	last   time.Time
+}
+
+func (l *LayerBench) OnAttach(e *core.Engine) {
+	w, h := e.Window.FramebufferSize()
+	l.cam = scene.NewOrtho2D(w, h)
+	l.cam.SetPosition(float32(w/2), float32(h/2)) // origin top-left
+
+	l.modes = []*benchMode{
+		{name: "static (in place)", usage: core.MeshStatic},
+		{name: "dynamic (orphan)", usage: core.MeshDynamic},
+		{name: "stream (fenced ring)", usage: core.MeshStream},
+	}
+	for _, m := range l.modes {
+		var err error
+		m.r2d, err = renderer2d.NewWithOptions(e.Renderer, renderer2d.Options{
+			Vertex: l.vs, Fragment: l.fs, MaxQuads: benchBatchQuads, Usage: m.usage,
+		})
+		if err != nil {
+			panic(err)
+		}
+	}
+	log.Printf("bench: %d quads in batches of %d, %d frames per mode", benchQuads, benchBatchQuads, benchFramesEach)
+}
+
+func (l *LayerBench) OnDetach(e *core.Engine)             {}
+func (l *LayerBench) OnUpdate(e *core.Engine, dt float64) {}
+
+func (l *LayerBench) OnRender(e *core.Engine, alpha float64) {
+	scopeRender := profiler.Start("LayerBench.OnRender")
+	defer scopeRender.End()
+
+	m := l.modes[l.cur]
+	now := time.Now()
+	if l.frame >= benchWarmupFrame {
+		m.frames++
+		m.cpu += now.Sub(l.last)
+		m.gpu += e.Renderer.GPUFrameTime()
+	}
+	l.last = now
+
+	w, h := e.Window.FramebufferSize()
+	t := float32(e.Uptime().Seconds())
+	m.r2d.BeginScene(l.cam.VP())
+	for i := 0; i < benchQuads; i++ {
+		fi := float32(i)
+		x := float32(math.Mod(float64(fi*7.31+t*40), float64(w)))
+		y := float32(math.Mod(float64(fi*3.17), float64(h)))
+		m.r2d.DrawQuad(x, y, 4, 4, colors.Color{fi / benchQuads, 0.5, 1 - fi/benchQuads, 1}, 0)
+	}
+	m.r2d.EndScene()
+	if l.frame >= benchWarmupFrame {
+		m.draws += m.r2d.Stats().DrawCalls
+	}
+
+	l.frame++
+	if m.frames < benchFramesEach {
+		return
+	}
+	cpu := m.cpu / time.Duration(m.frames)
+	log.Printf("bench: %-22s %7.3f ms/frame  GPU %7.3f ms  %9.0f draws/s",
+		m.name, ms(cpu), ms(m.gpu/time.Duration(m.frames)), float64(m.draws)/m.cpu.Seconds())
+
+	*m = benchMode{name: m.name, usage: m.usage, r2d: m.r2d}
+	l.cur = (l.cur + 1) % len(l.modes)
+	l.frame = 0
+}
+
+func (l *LayerBench) OnEvent(e *core.Engine, ev core.Event) bool {
+	if v, ok := ev.(core.EventResize); ok {
+		l.cam.SetViewportPixels(v.W, v.H)
+		l.cam.SetPosition(float32(v.W/2), float32(v.H/2))
+	}
+	return false
+}
+
+func ms(d time.Duration) float64 { return float64(d.Microseconds()) / 1000 }
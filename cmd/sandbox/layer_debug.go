@@ -3,7 +3,9 @@ package main
 import (
 	"fmt"
 	"log"
+	"math"
 
+	"github.com/hubastard/grove/engine/capture"
 	"github.com/hubastard/grove/engine/colors"
 	"github.com/hubastard/grove/engine/core"
 	"github.com/hubastard/grove/engine/gfx/renderer2d"
@@ -25,6 +27,11 @@ type LayerDebug struct {
 	ctx           *ui.Ctx
 	lastAllocs    uint64
 	allocs        uint64
+	rec           *capture.Recorder
+	frameTimes    [120]float32 // ms, ring buffer for the frame time graph
+	frameHead     int
+	graph         [][2]float32
+	frame         ui.Image // nine-slice button frame
 }
 
 type UIRenderer struct {
@@ -36,6 +43,22 @@ type UIRenderer struct {
 func (u *UIRenderer) DrawQuad(cx, cy, w, h float32, color [4]float32, rotation float32) {
 	u.r2d.DrawQuad(cx, cy, w, h, color, rotation)
 }
+func (u *UIRenderer) DrawRoundedRect(cx, cy, w, h, radius float32, color [4]float32) {
+	u.r2d.DrawRoundedRect(cx, cy, w, h, radius, color)
+}
+func (u *UIRenderer) DrawImage(x, y, w, h float32, img ui.Image, tint [4]float32) {
+	sub, ok := img.Source.(renderer2d.SubTexture2D)
+	if !ok {
+		u.r2d.DrawQuad(x+w/2, y+h/2, w, h, tint, 0) // not an image of ours
+		return
+	}
+	opts := renderer2d.NineSliceOptions{}
+	if img.Tile {
+		opts.Edges, opts.Center = renderer2d.SliceTile, renderer2d.SliceTile
+	}
+	insets := renderer2d.Insets{Left: img.Slice.L, Top: img.Slice.T, Right: img.Slice.R, Bottom: img.Slice.B}
+	u.r2d.DrawNineSliceWithOptions(renderer2d.Rect{X: x, Y: y, W: w, H: h}, sub, insets, tint, opts)
+}
 func (u *UIRenderer) DrawText(x, y float32, str string, size float32, color [4]float32) {
 	text.DrawText(u.r2d, u.font, x, y, str, color)
 }
@@ -53,9 +76,27 @@ func (l *LayerDebug) OnAttach(e *core.Engine) {
 	l.ctx = ui.New(64, 512, 512)
 	l.ctx.R = &UIRenderer{r2d: l.r2d, font: l.font}
 	l.ctx.I = &ui.Input{}
+
+	tex, err := e.Renderer.CreateTexture(core.TextureDesc{
+		Width: frameSize, Height: frameSize,
+		Format:    core.TextureRGBA8,
+		Pixels:    framePixels(),
+		MinFilter: "nearest", MagFilter: "nearest",
+		WrapU: "clamp", WrapV: "clamp",
+		Label: "LayerDebug.frame",
+	})
+	if err != nil {
+		panic(err)
+	}
+	l.frame = ui.Image{Source: renderer2d.FromTexture(tex, frameSize, frameSize), Slice: ui.Insets(6, 6, 6, 6)}
+
+	l.rec = capture.NewRecorder(capture.Options{Format: capture.FormatGIF, EveryN: 2, Scale: 0.5})
 }
 
-func (l *LayerDebug) OnDetach(e *core.Engine) {}
+func (l *LayerDebug) OnDetach(e *core.Engine) {
+	l.rec.Stop()
+	l.rec.Wait()
+}
 
 func (l *LayerDebug) OnUpdate(e *core.Engine, dt float64) {
 	l.ctx.I.MouseX, l.ctx.I.MouseY = e.Input.MousePosition()
@@ -74,19 +115,29 @@ func (l *LayerDebug) OnUpdate(e *core.Engine, dt float64) {
 		Padding:   ui.Insets(24, 24, 24, 24),
 		Gap:       8,
 		Bg:        colors.Black.WithAlpha(0.5),
+		Radius:    12,
 	})
 
+	if l.rec.Recording() {
+		ui.Label(ui.LabelProps{Text: scratch.Sprintf("REC %d frames (Ctrl+R to stop)", l.rec.Frames()), Color: colors.Red})
+	}
 	ui.Label(ui.LabelProps{Text: scratch.Sprintf("Frame: %d", l.tick), Color: colors.Yellow})
 	ui.Label(ui.LabelProps{Text: scratch.Sprintf("\t%.3f ms (%.2f FPS)", l.frameDuration, 1000.0/l.frameDuration)})
+	ui.Label(ui.LabelProps{Text: scratch.Sprintf("\tGPU: %.3f ms", float32(e.Renderer.GPUFrameTime().Microseconds())/1000.0)})
 	ui.Label(ui.LabelProps{Text: "2D Renderer", Color: colors.Yellow})
-	ui.Label(ui.LabelProps{Text: scratch.Sprintf("\tDraw Calls: %d\n\tQuads: %d\n\tVertices: %d\n\tTextures: %d", l.stats.DrawCalls, l.stats.QuadCount, l.stats.TotalVertexCount(), l.stats.TextureCount)})
+	ui.Label(ui.LabelProps{Text: scratch.Sprintf("\tDraw Calls: %d\n\tQuads: %d\n\tShapes: %d\n\tVertices: %d\n\tIndices: %d\n\tTextures: %d", l.stats.DrawCalls, l.stats.QuadCount, l.stats.ShapeCount, l.stats.TotalVertexCount(), l.stats.TotalIndexCount(), l.stats.TextureCount)})
+	rs := e.Renderer.Stats()
+	ui.Label(ui.LabelProps{Text: "GL State", Color: colors.Yellow})
+	ui.Label(ui.LabelProps{Text: scratch.Sprintf("\tDraws: %d\n\tState Changes: %d\n\tSkipped: %d\n\tBuffer Waits: %d", rs.DrawCalls, rs.StateChanges, rs.StateSkipped, rs.BufferWaits)})
 	ui.Label(ui.LabelProps{Text: "Memory", Color: colors.Yellow})
 	ui.Label(ui.LabelProps{Text: scratch.Sprintf("\tUsage: %.3f MB\n\tTotal Allocs: %d\n\tFrame Allocs: %d\n\tGoroutines: %d", float32(profiler.MemoryUsage())/(1<<20), l.allocs, l.allocs-l.lastAllocs, profiler.NumGoroutine())})
 	ui.Label(ui.LabelProps{Text: "Hardware", Color: colors.Yellow})
 	ui.Label(ui.LabelProps{Text: scratch.Sprintf("\tCPU Cores: %d", profiler.NumCPU())})
 	ui.Label(ui.LabelProps{Text: scratch.Sprintf("\tGPU: %s - v%s", e.Renderer.GPURenderer(), e.Renderer.GPUVersion())})
+	caps := e.Renderer.Caps()
+	ui.Label(ui.LabelProps{Text: scratch.Sprintf("\tTexture Units: %d\n\tMax Texture Size: %d\n\tMax Samples: %d", caps.MaxTextureUnits, caps.MaxTextureSize, caps.MaxSamples)})
 
-	if ui.Button(ui.ButtonProps{ID: 2, Text: "Click Me!", Padding: ui.Insets(16, 8, 16, 8), Bg: colors.Blue}) {
+	if ui.Button(ui.ButtonProps{ID: 2, Text: "Click Me!", Padding: ui.Insets(16, 8, 16, 8), Bg: colors.Blue, BgImage: l.frame}) {
 		fmt.Println("Button clicked!")
 	}
 
@@ -96,8 +147,16 @@ func (l *LayerDebug) OnUpdate(e *core.Engine, dt float64) {
 func (l *LayerDebug) OnRender(e *core.Engine, alpha float64) {
 	scopeRender := profiler.Start("LayerDebug.OnRender")
 
+	// Grab the clip frame before the overlay is drawn on top.
+	l.rec.Capture(e)
+
+	l.frameTimes[l.frameHead] = l.frameDuration
+	l.frameHead = (l.frameHead + 1) % len(l.frameTimes)
+
 	l.r2d.BeginScene(l.cam.VP())
 	ui.Flush(l.ctx)
+	w, _ := e.Window.FramebufferSize()
+	l.drawFrameGraph(float32(w)-264, 24, 240, 64)
 	l.r2d.EndScene()
 
 	scopeRender.End()
@@ -106,6 +165,53 @@ func (l *LayerDebug) OnRender(e *core.Engine, alpha float64) {
 	l.allocs = profiler.MemoryAllocs()
 }
 
+const frameSize = 16
+
+// framePixels draws a white rounded frame with a light gray fill, meant to
+// be tinted and nine-sliced with 6px insets.
+func framePixels() []byte {
+	pix := make([]byte, frameSize*frameSize*4)
+	const r = 5.0 // corner radius
+	for y := 0; y < frameSize; y++ {
+		for x := 0; x < frameSize; x++ {
+			// distance outside the rounded rect inset by r
+			fx, fy := float64(x)+0.5, float64(y)+0.5
+			dx := max(r-fx, fx-(frameSize-r), 0)
+			dy := max(r-fy, fy-(frameSize-r), 0)
+			d := math.Hypot(dx, dy) // 0 inside, r at the outer edge
+			p := pix[(y*frameSize+x)*4:]
+			switch {
+			case d > r:
+				continue // transparent corner
+			case d > r-1.5: // outline
+				p[0], p[1], p[2], p[3] = 255, 255, 255, 255
+			default:
+				p[0], p[1], p[2], p[3] = 190, 190, 190, 255
+			}
+		}
+	}
+	return pix
+}
+
+// drawFrameGraph plots recent frame times in a w x h box at (x, y); the
+// dashed line marks 16.6 ms.
+func (l *LayerDebug) drawFrameGraph(x, y, w, h float32) {
+	const maxMs = 33.3
+	l.r2d.DrawRoundedRect(x+w/2, y+h/2, w+16, h+16, 8, colors.Black.WithAlpha(0.5))
+	for dx := float32(0); dx < w; dx += 12 {
+		ly := y + h - h*16.6/maxMs
+		l.r2d.DrawLine(x+dx, ly, x+min(dx+6, w), ly, 1, colors.Gray)
+	}
+
+	l.graph = l.graph[:0]
+	n := len(l.frameTimes)
+	for i := 0; i < n; i++ {
+		ms := min(l.frameTimes[(l.frameHead+i)%n], maxMs)
+		l.graph = append(l.graph, [2]float32{x + w*float32(i)/float32(n-1), y + h - h*ms/maxMs})
+	}
+	l.r2d.DrawPolyline(l.graph, false, renderer2d.LineStyle{Width: 2, Join: renderer2d.JoinRound, Cap: renderer2d.CapRound}, colors.Green)
+}
+
 func (l *LayerDebug) OnEvent(e *core.Engine, ev core.Event) bool {
 	switch v := ev.(type) {
 	case core.EventKey:
@@ -117,6 +223,12 @@ func (l *LayerDebug) OnEvent(e *core.Engine, ev core.Event) bool {
 			}
 			return true
 		}
+		if v.Down && v.Key == core.KeyR && (v.Mods&core.ModCtrl) != 0 {
+			if err := l.rec.Toggle(); err != nil {
+				log.Printf("capture error: %v\n", err)
+			}
+			return true
+		}
 	case core.EventResize:
 		l.cam.SetViewportPixels(v.W, v.H)
 		l.cam.SetPosition(float32(v.W/2), float32(v.H/2)) // origin top-left
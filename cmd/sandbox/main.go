@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"log"
 	"time"
 
@@ -8,6 +9,7 @@ import (
 	"github.com/hubastard/grove/engine/colors"
 	"github.com/hubastard/grove/engine/core"
 	glbackend "github.com/hubastard/grove/engine/gfx/gl"
+	"github.com/hubastard/grove/engine/gfx/postfx"
 	"github.com/hubastard/grove/engine/gfx/renderer2d"
 	"github.com/hubastard/grove/engine/platform"
 	"github.com/hubastard/grove/engine/profiler"
@@ -15,6 +17,8 @@ import (
 )
 
 type App struct {
+	bench      bool
+	linear     bool
 	lastFrame  time.Time
 	tick       int
 	r2d        *renderer2d.Renderer2D
@@ -28,16 +32,16 @@ func (a *App) OnStart(e *core.Engine) {
 	profiler.Init(1 << 10) // ~1K scope samples
 
 	// Load 2D shader
-	vs, err := assets.LoadShader("renderer2d.vert")
+	vs, err := assets.LoadShaderSource("renderer2d.vert")
 	if err != nil {
 		panic(err)
 	}
-	fs, err := assets.LoadShader("renderer2d.frag")
+	fs, err := assets.LoadShaderSource("renderer2d.frag")
 	if err != nil {
 		panic(err)
 	}
 
-	a.r2d, err = renderer2d.New(e.Renderer, vs, fs, 10000)
+	a.r2d, err = renderer2d.NewFromSources(e.Renderer, vs, fs, 10000)
 	if err != nil {
 		panic(err)
 	}
@@ -48,9 +52,29 @@ func (a *App) OnStart(e *core.Engine) {
 		panic(err)
 	}
 
-	// push the 2D demo layer
-	a.layer = &Layer2D{r2d: a.r2d}
-	e.Layers.Push(a.layer)
+	// push the 2D demo layer, or the upload benchmark
+	if a.bench {
+		e.Layers.Push(&LayerBench{vs: vs, fs: fs})
+	} else {
+		pvs, err := assets.LoadShaderSource("fullscreen.vert")
+		if err != nil {
+			panic(err)
+		}
+		pfs, err := assets.LoadShaderSource("fxaa.frag")
+		if err != nil {
+			panic(err)
+		}
+		post, err := postfx.New(e.Renderer, pvs, pfs)
+		if err != nil {
+			panic(err)
+		}
+		clear := colors.DarkGray
+		if a.linear {
+			clear = clear.Linear()
+		}
+		a.layer = &Layer2D{r2d: a.r2d, post: post, clear: clear}
+		e.Layers.Push(a.layer)
+	}
 
 	a.debugLayer = &LayerDebug{r2d: a.r2d, font: a.font, stats: &a.stats}
 	e.Layers.Push(a.debugLayer)
@@ -74,16 +98,25 @@ func (a *App) OnEvent(e *core.Engine, ev core.Event) {}
 func (a *App) OnShutdown(e *core.Engine)             {}
 
 func main() {
+	glDebug := flag.Bool("gldebug", false, "create a debug GL context and log GL errors")
+	bench := flag.Bool("bench", false, "benchmark batch uploads per mesh usage (disables vsync)")
+	msaa := flag.Int("msaa", 4, "MSAA samples of the window framebuffer (0 disables)")
+	linear := flag.Bool("linear", false, "gamma-correct rendering (sRGB framebuffer and textures)")
+	flag.Parse()
+
 	cfg := core.Config{
 		Title:                "Go Engine (2D)",
 		Width:                1280,
 		Height:               720,
-		VSync:                true,
+		VSync:                !*bench,
 		ClearColor:           colors.DarkGray,
 		ScratchAllocCapacity: 4096, // 4 KB initial capacity
 		ScratchEnableLogs:    true,
+		GLDebug:              *glDebug,
+		MSAASamples:          *msaa,
+		LinearColor:          *linear,
 	}
-	app := &App{}
+	app := &App{bench: *bench, linear: *linear}
 
 	newWindow := func(cfg core.Config) (core.Window, error) {
 		return platform.NewGLFWWindow(cfg, nil)
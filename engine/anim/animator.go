@@ -0,0 +1,122 @@
+package anim
+
+import "github.com/hubastard/grove/engine/gfx/renderer2d"
+
+// Animator plays one clip at a time. Advance it from OnUpdate (the fixed
+// tick) and draw Sub from OnRender.
+type Animator struct {
+	Speed   float32 // playback rate, 0 => 1
+	Paused  bool
+	OnEvent func(event string) // frame events, and "end" when a Once clip stops
+
+	clip  *Clip
+	frame int
+	dir   int     // +1, or -1 on the way back of a PingPong clip
+	time  float32 // into the current frame
+	enter bool    // the current frame's events are still to be sent
+	done  bool
+}
+
+// Play switches to c from its first frame. Playing the clip that is already
+// running (and not done) changes nothing, so it can be called every tick.
+func (a *Animator) Play(c *Clip) {
+	if c == a.clip && !a.done {
+		return
+	}
+	a.clip = c
+	a.Restart()
+}
+
+// Restart plays the current clip again from its first frame.
+func (a *Animator) Restart() {
+	a.frame, a.dir, a.time = 0, 1, 0
+	a.done = false
+	a.enter = a.clip != nil && len(a.clip.Frames) > 0
+}
+
+// Clip is the clip playing, or nil.
+func (a *Animator) Clip() *Clip { return a.clip }
+
+// Frame is the index of the current frame.
+func (a *Animator) Frame() int { return a.frame }
+
+// Done reports whether a Once clip has reached its end.
+func (a *Animator) Done() bool { return a.done }
+
+// Sub is the image of the current frame (zero without a clip).
+func (a *Animator) Sub() renderer2d.SubTexture2D {
+	if a.clip == nil || len(a.clip.Frames) == 0 {
+		return renderer2d.SubTexture2D{}
+	}
+	return a.clip.Frames[a.frame].Sub
+}
+
+// Update advances the clip by dt seconds, sending the events of every frame
+// it starts.
+func (a *Animator) Update(dt float32) {
+	if a.clip == nil || len(a.clip.Frames) == 0 || a.Paused {
+		return
+	}
+	if a.enter {
+		a.enter = false
+		a.emit()
+	}
+	if a.done || a.clip.Duration() <= 0 {
+		return
+	}
+
+	speed := a.Speed
+	if speed == 0 {
+		speed = 1
+	}
+	a.time += dt * speed
+	for a.time >= a.clip.Frames[a.frame].Duration {
+		a.time -= a.clip.Frames[a.frame].Duration
+		if !a.step() {
+			a.time = 0
+			a.done = true
+			a.send("end")
+			return
+		}
+		a.emit()
+	}
+}
+
+// step moves to the next frame; false when a Once clip is over.
+func (a *Animator) step() bool {
+	n := len(a.clip.Frames)
+	next := a.frame + a.dir
+	switch a.clip.Mode {
+	case Once:
+		if next >= n {
+			return false
+		}
+	case PingPong:
+		switch {
+		case n == 1:
+			next = 0
+		case next >= n:
+			a.dir, next = -1, n-2
+		case next < 0:
+			a.dir, next = 1, 1
+		}
+	default:
+		if next >= n {
+			next = 0
+		}
+	}
+	a.frame = next
+	return true
+}
+
+func (a *Animator) emit() {
+	for _, ev := range a.clip.Frames[a.frame].Events {
+		a.send(ev)
+	}
+}
+
+func (a *Animator) send(event string) {
+	if a.OnEvent != nil {
+		a.OnEvent(event)
+	}
+}
@@ -0,0 +1,103 @@
+package anim
+
+import (
+	"slices"
+	"strconv"
+	"testing"
+)
+
+// testClip returns a clip of n frames of dur seconds; each frame sends its
+// index as an event, so the events list every frame entered.
+func testClip(mode Mode, n int, dur float32) *Clip {
+	c := &Clip{Name: "test", Mode: mode, Frames: make([]Frame, n)}
+	for i := range c.Frames {
+		c.Frames[i].Duration = dur
+		c.AddEvent(i, strconv.Itoa(i))
+	}
+	return c
+}
+
+func TestAnimator(t *testing.T) {
+	tests := []struct {
+		name   string
+		clip   *Clip
+		speed  float32
+		steps  []float32
+		events []string
+		frame  int
+		done   bool
+	}{
+		{"loop", testClip(Loop, 3, 0.25), 0, []float32{0.25, 0.25, 0.25},
+			[]string{"0", "1", "2", "0"}, 0, false},
+		{"loop just before the boundary", testClip(Loop, 3, 0.25), 0, []float32{0.125, 0.124},
+			[]string{"0"}, 0, false},
+		{"loop on the boundary", testClip(Loop, 3, 0.25), 0, []float32{0.125, 0.125},
+			[]string{"0", "1"}, 1, false},
+		{"loop large dt", testClip(Loop, 3, 0.25), 0, []float32{1.75},
+			[]string{"0", "1", "2", "0", "1", "2", "0", "1"}, 1, false},
+		{"once", testClip(Once, 3, 0.25), 0, []float32{0.25, 0.25},
+			[]string{"0", "1", "2"}, 2, false},
+		{"once ends after the last frame", testClip(Once, 3, 0.25), 0, []float32{0.25, 0.25, 0.25, 1},
+			[]string{"0", "1", "2", "end"}, 2, true},
+		{"once large dt", testClip(Once, 3, 0.25), 0, []float32{10},
+			[]string{"0", "1", "2", "end"}, 2, true},
+		{"ping-pong", testClip(PingPong, 3, 0.25), 0, []float32{0.25, 0.25, 0.25, 0.25, 0.25, 0.25},
+			[]string{"0", "1", "2", "1", "0", "1", "2"}, 2, false},
+		{"ping-pong large dt", testClip(PingPong, 3, 0.25), 0, []float32{1.75},
+			[]string{"0", "1", "2", "1", "0", "1", "2", "1"}, 1, false},
+		{"ping-pong of one frame", testClip(PingPong, 1, 0.25), 0, []float32{0.5},
+			[]string{"0", "0", "0"}, 0, false},
+		{"ping-pong of two frames", testClip(PingPong, 2, 0.25), 0, []float32{1},
+			[]string{"0", "1", "0", "1", "0"}, 0, false},
+		{"speed", testClip(Loop, 3, 0.25), 2, []float32{0.25},
+			[]string{"0", "1", "2"}, 2, false},
+		{"zero duration", testClip(Loop, 3, 0), 0, []float32{1, 1},
+			[]string{"0"}, 0, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var events []string
+			a := &Animator{Speed: tc.speed, OnEvent: func(ev string) { events = append(events, ev) }}
+			a.Play(tc.clip)
+			for _, dt := range tc.steps {
+				a.Update(dt)
+			}
+			if !slices.Equal(events, tc.events) {
+				t.Errorf("events %v, want %v", events, tc.events)
+			}
+			if a.Frame() != tc.frame || a.Done() != tc.done {
+				t.Errorf("frame %d, done %v; want %d, %v", a.Frame(), a.Done(), tc.frame, tc.done)
+			}
+		})
+	}
+}
+
+func TestAnimatorPlay(t *testing.T) {
+	walk, jump := testClip(Loop, 3, 0.25), testClip(Once, 2, 0.25)
+	a := &Animator{}
+	a.Play(walk)
+	a.Update(0.25)
+	a.Play(walk) // already playing: keeps going
+	if a.Frame() != 1 {
+		t.Errorf("replaying the running clip restarted it: frame %d", a.Frame())
+	}
+
+	a.Play(jump)
+	if a.Clip() != jump || a.Frame() != 0 {
+		t.Errorf("switching clips: clip %p frame %d", a.Clip(), a.Frame())
+	}
+	a.Update(1)
+	if !a.Done() {
+		t.Fatal("jump not done")
+	}
+	a.Play(jump) // done: plays again
+	if a.Done() || a.Frame() != 0 {
+		t.Errorf("replaying a finished clip: frame %d, done %v", a.Frame(), a.Done())
+	}
+
+	a.Paused = true
+	a.Update(1)
+	if a.Frame() != 0 {
+		t.Errorf("paused animator advanced to frame %d", a.Frame())
+	}
+}
@@ -0,0 +1,229 @@
+package anim
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/hubastard/grove/engine/core"
+	"github.com/hubastard/grove/engine/gfx/renderer2d"
+)
+
+// -------- Aseprite --------
+
+// Sheet is a sprite sheet exported by Aseprite (File > Export Sprite Sheet,
+// JSON data with frame tags and slices; hash or array layout).
+type Sheet struct {
+	Image  string  // image named by the export, relative to the JSON file
+	Frames []Frame // every frame, in export order
+	Clips  map[string]*Clip
+	Slices map[string]*Slice
+}
+
+// Slice is an Aseprite slice: a named rectangle, possibly changing per frame.
+type Slice struct {
+	Name string
+	Keys []SliceKey // by frame
+}
+
+// SliceKey is a slice from Frame on.
+type SliceKey struct {
+	Frame  int
+	Bounds renderer2d.Rect // in the frame, in pixels
+
+	// Nine-slice insets, for renderer2d.DrawNineSlice.
+	Center    renderer2d.Insets
+	HasCenter bool
+
+	Pivot    [2]float32 // relative to Bounds, in pixels
+	HasPivot bool
+}
+
+// At returns the key in effect on frame.
+func (s *Slice) At(frame int) SliceKey {
+	k := s.Keys[0]
+	for _, key := range s.Keys[1:] {
+		if key.Frame > frame {
+			break
+		}
+		k = key
+	}
+	return k
+}
+
+// LoadAseprite reads an Aseprite JSON export from assets/textures whose
+// image is uploaded as tex.
+//
+// Each frame tag becomes a clip: "forward" loops, "pingpong" ping-pongs,
+// "reverse" and "pingpong_reverse" do the same over the reversed frames, and
+// a forward tag repeated once plays Once. Frame durations come from the
+// export.
+func LoadAseprite(relPath string, tex core.Texture) (*Sheet, error) {
+	path := filepath.Join("assets", "textures", relPath)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read aseprite %q: %w", path, err)
+	}
+	sheet, err := ParseAseprite(data, tex)
+	if err != nil {
+		return nil, fmt.Errorf("parse aseprite %q: %w", path, err)
+	}
+	return sheet, nil
+}
+
+type aseRect struct{ X, Y, W, H float32 }
+
+type aseFrame struct {
+	Filename         string
+	Frame            aseRect
+	Rotated          bool
+	SpriteSourceSize aseRect
+	Duration         float32 // ms
+}
+
+type aseFile struct {
+	Frames json.RawMessage
+	Meta   struct {
+		Image     string
+		Size      struct{ W, H float32 }
+		FrameTags []struct {
+			Name      string
+			From, To  int
+			Direction string
+			Repeat    string
+		}
+		Slices []struct {
+			Name string
+			Keys []struct {
+				Frame  int
+				Bounds aseRect
+				Center *aseRect
+				Pivot  *struct{ X, Y float32 }
+			}
+		}
+	}
+}
+
+// ParseAseprite is LoadAseprite on the JSON data.
+func ParseAseprite(data []byte, tex core.Texture) (*Sheet, error) {
+	var f aseFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+	if f.Meta.Size.W <= 0 || f.Meta.Size.H <= 0 {
+		return nil, fmt.Errorf("missing meta.size")
+	}
+	frames, err := aseFrames(f.Frames)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Sheet{
+		Image:  f.Meta.Image,
+		Frames: make([]Frame, len(frames)),
+		Clips:  make(map[string]*Clip, len(f.Meta.FrameTags)),
+		Slices: make(map[string]*Slice, len(f.Meta.Slices)),
+	}
+	aw, ah := f.Meta.Size.W, f.Meta.Size.H
+	for i, fr := range frames {
+		if fr.Rotated {
+			return nil, fmt.Errorf("frame %q: rotated frames are not supported", fr.Filename)
+		}
+		r := fr.Frame
+		s.Frames[i] = Frame{
+			Sub: renderer2d.SubTexture2D{
+				Texture: tex,
+				U0:      r.X / aw, V0: r.Y / ah,
+				U1: (r.X + r.W) / aw, V1: (r.Y + r.H) / ah,
+				W: r.W, H: r.H,
+			},
+			Duration: fr.Duration / 1000,
+			Source:   renderer2d.Rect(fr.SpriteSourceSize),
+		}
+	}
+
+	for _, t := range f.Meta.FrameTags {
+		if t.From < 0 || t.To >= len(s.Frames) || t.From > t.To {
+			return nil, fmt.Errorf("tag %q: frames %d..%d out of range", t.Name, t.From, t.To)
+		}
+		c := &Clip{Name: t.Name, Frames: slices.Clone(s.Frames[t.From : t.To+1])}
+		switch t.Direction {
+		case "", "forward":
+			if t.Repeat == "1" {
+				c.Mode = Once
+			}
+		case "reverse":
+			slices.Reverse(c.Frames)
+		case "pingpong":
+			c.Mode = PingPong
+		case "pingpong_reverse":
+			slices.Reverse(c.Frames)
+			c.Mode = PingPong
+		default:
+			return nil, fmt.Errorf("tag %q: unknown direction %q", t.Name, t.Direction)
+		}
+		s.Clips[t.Name] = c
+	}
+
+	for _, sl := range f.Meta.Slices {
+		if len(sl.Keys) == 0 {
+			continue
+		}
+		out := &Slice{Name: sl.Name, Keys: make([]SliceKey, len(sl.Keys))}
+		for i, k := range sl.Keys {
+			key := SliceKey{Frame: k.Frame, Bounds: renderer2d.Rect(k.Bounds)}
+			if c := k.Center; c != nil {
+				key.Center = renderer2d.Insets{
+					Left: c.X, Top: c.Y,
+					Right: k.Bounds.W - c.X - c.W, Bottom: k.Bounds.H - c.Y - c.H,
+				}
+				key.HasCenter = true
+			}
+			if p := k.Pivot; p != nil {
+				key.Pivot = [2]float32{p.X, p.Y}
+				key.HasPivot = true
+			}
+			out.Keys[i] = key
+		}
+		slices.SortStableFunc(out.Keys, func(a, b SliceKey) int { return a.Frame - b.Frame })
+		s.Slices[sl.Name] = out
+	}
+	return s, nil
+}
+
+// aseFrames decodes the frames of either layout. In the hash layout the
+// export order is the key order, which a Go map would lose.
+func aseFrames(raw json.RawMessage) ([]aseFrame, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || raw[0] != '[' && raw[0] != '{' {
+		return nil, fmt.Errorf("frames: want an array or an object")
+	}
+	var frames []aseFrame
+	if raw[0] == '[' {
+		if err := json.Unmarshal(raw, &frames); err != nil {
+			return nil, fmt.Errorf("frames: %w", err)
+		}
+		return frames, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if _, err := dec.Token(); err != nil { // {
+		return nil, fmt.Errorf("frames: %w", err)
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("frames: %w", err)
+		}
+		var fr aseFrame
+		if err := dec.Decode(&fr); err != nil {
+			return nil, fmt.Errorf("frame %q: %w", tok, err)
+		}
+		fr.Filename = tok.(string)
+		frames = append(frames, fr)
+	}
+	return frames, nil
+}
@@ -0,0 +1,164 @@
+package anim
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hubastard/grove/engine/gfx/renderer2d"
+)
+
+// aseFrameJSON is frame i of a 64x16 sheet of 16x16 cells, trimmed by one
+// pixel on the left and lasting (i+1)*100ms.
+func aseFrameJSON(i int) string {
+	return fmt.Sprintf(`{"frame": {"x": %d, "y": 0, "w": 15, "h": 16}, "rotated": false, "trimmed": true,
+		"spriteSourceSize": {"x": 1, "y": 0, "w": 15, "h": 16}, "sourceSize": {"w": 16, "h": 16}, "duration": %d}`,
+		16*i, 100*(i+1))
+}
+
+const aseMeta = `"meta": {
+	"image": "hero.png",
+	"size": {"w": 64, "h": 16},
+	"frameTags": [
+		{"name": "fwd", "from": 0, "to": 2, "direction": "forward"},
+		{"name": "rev", "from": 0, "to": 2, "direction": "reverse"},
+		{"name": "pp", "from": 1, "to": 3, "direction": "pingpong"},
+		{"name": "ppr", "from": 1, "to": 3, "direction": "pingpong_reverse"},
+		{"name": "once", "from": 2, "to": 3, "direction": "forward", "repeat": "1"},
+		{"name": "plain", "from": 3, "to": 3}
+	],
+	"slices": [
+		{"name": "panel", "keys": [
+			{"frame": 2, "bounds": {"x": 0, "y": 0, "w": 8, "h": 8}},
+			{"frame": 0, "bounds": {"x": 0, "y": 0, "w": 16, "h": 16},
+				"center": {"x": 4, "y": 5, "w": 8, "h": 6}, "pivot": {"x": 8, "y": 15}}
+		]}
+	]
+}`
+
+// aseHash names the frames out of alphabetical order: the export order must
+// win.
+var aseHash = `{"frames": {
+	"hero 3.aseprite": ` + aseFrameJSON(0) + `,
+	"hero 1.aseprite": ` + aseFrameJSON(1) + `,
+	"hero 2.aseprite": ` + aseFrameJSON(2) + `,
+	"hero 0.aseprite": ` + aseFrameJSON(3) + `
+}, ` + aseMeta + `}`
+
+var aseArray = `{"frames": [
+	{"filename": "hero 3.aseprite", ` + aseFrameJSON(0)[1:] + `,
+	{"filename": "hero 1.aseprite", ` + aseFrameJSON(1)[1:] + `,
+	{"filename": "hero 2.aseprite", ` + aseFrameJSON(2)[1:] + `,
+	{"filename": "hero 0.aseprite", ` + aseFrameJSON(3)[1:] + `
+], ` + aseMeta + `}`
+
+func TestParseAseprite(t *testing.T) {
+	for _, layout := range []struct{ name, json string }{{"hash", aseHash}, {"array", aseArray}} {
+		t.Run(layout.name, func(t *testing.T) {
+			s, err := ParseAseprite([]byte(layout.json), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s.Image != "hero.png" || len(s.Frames) != 4 {
+				t.Fatalf("image %q, %d frames", s.Image, len(s.Frames))
+			}
+			for i, f := range s.Frames {
+				want := Frame{
+					Sub:      renderer2d.SubTexture2D{U0: float32(16*i) / 64, U1: float32(16*i+15) / 64, V1: 1, W: 15, H: 16},
+					Duration: float32(100*(i+1)) / 1000,
+					Source:   renderer2d.Rect{X: 1, W: 15, H: 16},
+				}
+				if !reflect.DeepEqual(f, want) {
+					t.Errorf("frame %d: %+v, want %+v", i, f, want)
+				}
+			}
+
+			// clips list their frames by index into s.Frames
+			tags := []struct {
+				name   string
+				mode   Mode
+				frames []int
+			}{
+				{"fwd", Loop, []int{0, 1, 2}},
+				{"rev", Loop, []int{2, 1, 0}},
+				{"pp", PingPong, []int{1, 2, 3}},
+				{"ppr", PingPong, []int{3, 2, 1}},
+				{"once", Once, []int{2, 3}},
+				{"plain", Loop, []int{3}},
+			}
+			if len(s.Clips) != len(tags) {
+				t.Errorf("%d clips, want %d", len(s.Clips), len(tags))
+			}
+			for _, tag := range tags {
+				c := s.Clips[tag.name]
+				if c == nil {
+					t.Errorf("no clip %q", tag.name)
+					continue
+				}
+				var frames []int
+				for _, f := range c.Frames {
+					frames = append(frames, int(f.Sub.U0*4))
+				}
+				if c.Name != tag.name || c.Mode != tag.mode || !reflect.DeepEqual(frames, tag.frames) {
+					t.Errorf("clip %q: mode %d frames %v, want mode %d frames %v", tag.name, c.Mode, frames, tag.mode, tag.frames)
+				}
+			}
+
+			// clips copy their frames: events added to one don't leak
+			s.Clips["fwd"].AddEvent(0, "step")
+			if len(s.Frames[0].Events) != 0 || len(s.Clips["rev"].Frames[2].Events) != 0 {
+				t.Error("clip event shared with other clips")
+			}
+		})
+	}
+}
+
+func TestParseAsepriteSlices(t *testing.T) {
+	s, err := ParseAseprite([]byte(aseArray), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sl := s.Slices["panel"]
+	if sl == nil || len(sl.Keys) != 2 || sl.Keys[0].Frame != 0 || sl.Keys[1].Frame != 2 {
+		t.Fatalf("slice keys not sorted by frame: %+v", sl)
+	}
+	k := sl.At(1)
+	want := SliceKey{
+		Bounds:    renderer2d.Rect{W: 16, H: 16},
+		Center:    renderer2d.Insets{Left: 4, Top: 5, Right: 4, Bottom: 5},
+		HasCenter: true,
+		Pivot:     [2]float32{8, 15},
+		HasPivot:  true,
+	}
+	if k != want {
+		t.Errorf("At(1) = %+v, want %+v", k, want)
+	}
+	if k := sl.At(5); k.Frame != 2 || k.HasCenter || k.HasPivot {
+		t.Errorf("At(5) = %+v, want the frame 2 key", k)
+	}
+}
+
+func TestParseAsepriteErrors(t *testing.T) {
+	frame := `{"frames": [` + aseFrameJSON(0) + `], `
+	tests := []struct {
+		name string
+		json string
+		want string
+	}{
+		{"frames not a list", `{"frames": 3, "meta": {"size": {"w": 1, "h": 1}}}`, "want an array or an object"},
+		{"missing size", `{"frames": [], "meta": {}}`, "missing meta.size"},
+		{"rotated", strings.Replace(frame, `"rotated": false`, `"rotated": true`, 1) + `"meta": {"size": {"w": 64, "h": 16}}}`, "rotated"},
+		{"tag out of range", frame + `"meta": {"size": {"w": 64, "h": 16}, "frameTags": [{"name": "x", "from": 0, "to": 1}]}}`, "out of range"},
+		{"reversed tag", frame + `"meta": {"size": {"w": 64, "h": 16}, "frameTags": [{"name": "x", "from": 1, "to": 0}]}}`, "out of range"},
+		{"unknown direction", frame + `"meta": {"size": {"w": 64, "h": 16}, "frameTags": [{"name": "x", "from": 0, "to": 0, "direction": "sideways"}]}}`, "unknown direction"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseAseprite([]byte(tc.json), nil)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("error %v, want it to contain %q", err, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package anim
+
+import (
+	"github.com/hubastard/grove/engine/core"
+	"github.com/hubastard/grove/engine/gfx/renderer2d"
+)
+
+// Mode says what a clip does after its last frame.
+type Mode int
+
+const (
+	Loop     Mode = iota // start over
+	PingPong             // play backwards to the first frame, then forwards again
+	Once                 // stop on the last frame
+)
+
+// Frame is one image of a clip.
+type Frame struct {
+	Sub      renderer2d.SubTexture2D
+	Duration float32 // seconds
+
+	// Source is where Sub sits in the untrimmed frame, in pixels; it differs
+	// from (0, 0, Sub.W, Sub.H) only for trimmed Aseprite exports.
+	Source renderer2d.Rect
+
+	Events []string // sent to Animator.OnEvent when the frame starts
+}
+
+// Clip is a named sequence of frames.
+type Clip struct {
+	Name   string
+	Frames []Frame
+	Mode   Mode
+}
+
+// Duration is the time of one pass over the frames.
+func (c *Clip) Duration() float32 {
+	var d float32
+	for _, f := range c.Frames {
+		d += f.Duration
+	}
+	return d
+}
+
+// AddEvent sends event when frame starts; it returns c for chaining.
+func (c *Clip) AddEvent(frame int, event string) *Clip {
+	c.Frames[frame].Events = append(c.Frames[frame].Events, event)
+	return c
+}
+
+// -------- Grid sprite sheets --------
+
+// Grid is a sprite sheet of equal cells, numbered row by row from the
+// top-left (like renderer2d.FromGrid).
+type Grid struct {
+	Texture       core.Texture
+	Width, Height int // texture size in pixels
+	CellW, CellH  int
+
+	// Sheet within the texture (e.g. an atlas region), in pixels; a zero
+	// SheetW covers the whole texture.
+	X, Y, SheetW int
+}
+
+// Cols is the number of cells per row.
+func (g Grid) Cols() int {
+	if g.SheetW > 0 {
+		return g.SheetW / g.CellW
+	}
+	return g.Width / g.CellW
+}
+
+// Frame returns cell i.
+func (g Grid) Frame(i int) renderer2d.SubTexture2D {
+	cols := g.Cols()
+	x, y := g.X+i%cols*g.CellW, g.Y+i/cols*g.CellH
+	return renderer2d.FromPixels(g.Texture, x, y, g.CellW, g.CellH, g.Width, g.Height)
+}
+
+// Clip builds a clip showing cells for frameTime seconds each.
+func (g Grid) Clip(name string, mode Mode, frameTime float32, cells ...int) *Clip {
+	c := &Clip{Name: name, Mode: mode, Frames: make([]Frame, len(cells))}
+	for i, cell := range cells {
+		sub := g.Frame(cell)
+		c.Frames[i] = Frame{Sub: sub, Duration: frameTime, Source: renderer2d.Rect{W: sub.W, H: sub.H}}
+	}
+	return c
+}
+
+// Row builds a clip from count cells of row, starting at column first.
+func (g Grid) Row(name string, mode Mode, frameTime float32, row, first, count int) *Clip {
+	cells := make([]int, count)
+	for i := range cells {
+		cells[i] = row*g.Cols() + first + i
+	}
+	return g.Clip(name, mode, frameTime, cells...)
+}
@@ -0,0 +1,38 @@
+package anim
+
+import (
+	"testing"
+
+	"github.com/hubastard/grove/engine/gfx/renderer2d"
+)
+
+func TestGrid(t *testing.T) {
+	g := Grid{Width: 64, Height: 32, CellW: 16, CellH: 16}
+	if g.Cols() != 4 {
+		t.Fatalf("Cols = %d, want 4", g.Cols())
+	}
+
+	c := g.Row("walk", PingPong, 0.1, 1, 1, 3)
+	if c.Name != "walk" || c.Mode != PingPong || len(c.Frames) != 3 {
+		t.Fatalf("clip %q mode %d with %d frames", c.Name, c.Mode, len(c.Frames))
+	}
+	for i, f := range c.Frames {
+		want := renderer2d.FromPixels(nil, 16*(1+i), 16, 16, 16, 64, 32)
+		if f.Sub != want {
+			t.Errorf("frame %d: %+v, want %+v", i, f.Sub, want)
+		}
+		if f.Duration != 0.1 || f.Source != (renderer2d.Rect{W: 16, H: 16}) {
+			t.Errorf("frame %d: duration %g, source %+v", i, f.Duration, f.Source)
+		}
+	}
+	if d := c.Duration(); d < 0.3-1e-6 || d > 0.3+1e-6 {
+		t.Errorf("Duration = %g, want 0.3", d)
+	}
+
+	// cells continue on the next row
+	c = g.Clip("any", Once, 0.1, 3, 4)
+	if c.Frames[0].Sub != renderer2d.FromPixels(nil, 48, 0, 16, 16, 64, 32) ||
+		c.Frames[1].Sub != renderer2d.FromPixels(nil, 0, 16, 16, 16, 64, 32) {
+		t.Errorf("cells 3, 4: %+v, %+v", c.Frames[0].Sub, c.Frames[1].Sub)
+	}
+}
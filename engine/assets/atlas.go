@@ -0,0 +1,405 @@
+package assets
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+
+	"github.com/hubastard/grove/engine/core"
+	"github.com/hubastard/grove/engine/gfx/renderer2d"
+)
+
+// -------- Texture atlas --------
+
+// AtlasImage is an image to pack: tight RGBA8 rows, top-left origin (as
+// returned by LoadPNG).
+type AtlasImage struct {
+	Name   string
+	W, H   int
+	Pixels []byte
+}
+
+// AtlasOptions configures PackAtlas and LoadAtlas.
+type AtlasOptions struct {
+	MaxSize int // page width and height limit (default: 2048)
+	Padding int // transparent pixels between images
+	Extrude int // edge pixels repeated around each image, so filtering does not bleed
+
+	// CachePath, when set, makes LoadAtlas keep the packed pages next to it
+	// (CachePath.json, CachePath-0.png, ...) and reuse them while the source
+	// files and options are unchanged.
+	CachePath string
+}
+
+// AtlasPage is one packed RGBA8 image.
+type AtlasPage struct {
+	W, H   int
+	Pixels []byte
+}
+
+// AtlasRegion is where an image landed, in pixels of its page.
+type AtlasRegion struct {
+	Page       int
+	X, Y, W, H int
+}
+
+// Atlas is a set of images packed into as few pages as fit in MaxSize.
+type Atlas struct {
+	Pages   []AtlasPage
+	Regions map[string]AtlasRegion
+	uploads []core.TextureUpload
+}
+
+// PackAtlas packs images with MaxRects (best short side fit), largest
+// first, opening a new page whenever one does not fit the pages so far.
+// Pages are shrunk to the smallest power of two holding their images.
+func PackAtlas(images []AtlasImage, opts AtlasOptions) (*Atlas, error) {
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = 2048
+	}
+	pad, ext := max(opts.Padding, 0), max(opts.Extrude, 0)
+
+	a := &Atlas{Regions: make(map[string]AtlasRegion, len(images))}
+	for _, img := range images {
+		if _, dup := a.Regions[img.Name]; dup {
+			return nil, fmt.Errorf("pack atlas: duplicate image %q", img.Name)
+		}
+		if img.W <= 0 || img.H <= 0 || len(img.Pixels) != img.W*img.H*4 {
+			return nil, fmt.Errorf("pack atlas: image %q: %d bytes of pixels for %dx%d RGBA8", img.Name, len(img.Pixels), img.W, img.H)
+		}
+		a.Regions[img.Name] = AtlasRegion{}
+	}
+
+	order := make([]int, len(images))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(i, j int) int {
+		a, b := images[i], images[j]
+		return cmp.Or(cmp.Compare(max(b.W, b.H), max(a.W, a.H)), cmp.Compare(b.W*b.H, a.W*a.H))
+	})
+
+	var bins []*maxRects
+	for _, i := range order {
+		img := images[i]
+		w, h := img.W+2*ext+pad, img.H+2*ext+pad
+		if w-pad > opts.MaxSize || h-pad > opts.MaxSize {
+			return nil, fmt.Errorf("pack atlas: image %q (%dx%d) does not fit in %d", img.Name, img.W, img.H, opts.MaxSize)
+		}
+		page := -1
+		var r packRect
+		for p, bin := range bins {
+			var ok bool
+			if r, ok = bin.insert(w, h); ok {
+				page = p
+				break
+			}
+		}
+		if page < 0 {
+			// the padding may hang over the page edge
+			bins = append(bins, newMaxRects(opts.MaxSize+pad, opts.MaxSize+pad))
+			page = len(bins) - 1
+			r, _ = bins[page].insert(w, h)
+		}
+		a.Regions[img.Name] = AtlasRegion{Page: page, X: r.x + ext, Y: r.y + ext, W: img.W, H: img.H}
+	}
+
+	a.Pages = make([]AtlasPage, len(bins))
+	for p, bin := range bins {
+		a.Pages[p].W = min(ceilPow2(bin.usedW-pad), opts.MaxSize)
+		a.Pages[p].H = min(ceilPow2(bin.usedH-pad), opts.MaxSize)
+		a.Pages[p].Pixels = make([]byte, a.Pages[p].W*a.Pages[p].H*4)
+	}
+	for _, img := range images {
+		reg := a.Regions[img.Name]
+		blitExtruded(&a.Pages[reg.Page], img, reg.X, reg.Y, ext)
+	}
+	return a, nil
+}
+
+// blitExtruded copies img to (x, y) of page and repeats its edges ext
+// pixels outwards, corners included.
+func blitExtruded(page *AtlasPage, img AtlasImage, x, y, ext int) {
+	for dy := -ext; dy < img.H+ext; dy++ {
+		sy := min(max(dy, 0), img.H-1)
+		row := ((y+dy)*page.W + x) * 4
+		src := img.Pixels[sy*img.W*4 : (sy+1)*img.W*4]
+		copy(page.Pixels[row:], src)
+		for dx := 1; dx <= ext; dx++ {
+			copy(page.Pixels[row-dx*4:row-dx*4+4], src[:4])
+			end := row + img.W*4 + (dx-1)*4
+			copy(page.Pixels[end:end+4], src[len(src)-4:])
+		}
+	}
+}
+
+func ceilPow2(v int) int {
+	n := 1
+	for n < v {
+		n <<= 1
+	}
+	return n
+}
+
+// Upload creates a texture per page (asynchronously, see Ready) from desc,
+// which supplies filtering, wrap and label; size, format and pixels are
+// filled in.
+func (a *Atlas) Upload(r core.Renderer, desc core.TextureDesc) error {
+	label := desc.Label
+	a.uploads = a.uploads[:0]
+	for i, p := range a.Pages {
+		desc.Width, desc.Height = p.W, p.H
+		desc.Format = core.TextureRGBA8
+		desc.Pixels = p.Pixels
+		if label != "" {
+			desc.Label = label + "[" + strconv.Itoa(i) + "]"
+		}
+		u, err := r.CreateTextureAsync(desc, nil)
+		if err != nil {
+			return fmt.Errorf("upload atlas page %d: %w", i, err)
+		}
+		a.uploads = append(a.uploads, u)
+	}
+	return nil
+}
+
+// Ready reports whether every page has been uploaded.
+func (a *Atlas) Ready() bool {
+	for _, u := range a.uploads {
+		if !u.Ready() {
+			return false
+		}
+	}
+	return len(a.uploads) == len(a.Pages)
+}
+
+// Texture is the texture of page, once Upload has been called.
+func (a *Atlas) Texture(page int) core.Texture {
+	return a.uploads[page].Texture()
+}
+
+// Sub returns the named image once Upload has been called.
+func (a *Atlas) Sub(name string) (renderer2d.SubTexture2D, bool) {
+	reg, ok := a.Regions[name]
+	if !ok || reg.Page >= len(a.uploads) {
+		return renderer2d.SubTexture2D{}, false
+	}
+	p := a.Pages[reg.Page]
+	return renderer2d.FromPixels(a.Texture(reg.Page), reg.X, reg.Y, reg.W, reg.H, p.W, p.H), true
+}
+
+// Subs returns every image by name once Upload has been called.
+func (a *Atlas) Subs() map[string]renderer2d.SubTexture2D {
+	subs := make(map[string]renderer2d.SubTexture2D, len(a.Regions))
+	for name := range a.Regions {
+		if sub, ok := a.Sub(name); ok {
+			subs[name] = sub
+		}
+	}
+	return subs
+}
+
+// LoadAtlas packs PNG files from assets/textures, each named by its path.
+// With opts.CachePath it reads the pages from the cache instead when they
+// are up to date, and writes them there otherwise.
+func LoadAtlas(relPaths []string, opts AtlasOptions) (*Atlas, error) {
+	var key string
+	if opts.CachePath != "" {
+		var err error
+		if key, err = atlasKey(relPaths, opts); err != nil {
+			return nil, err
+		}
+		if a, ok := readAtlasCache(opts.CachePath, key); ok {
+			return a, nil
+		}
+	}
+
+	images := make([]AtlasImage, len(relPaths))
+	for i, rel := range relPaths {
+		w, h, pix, err := LoadPNG(rel)
+		if err != nil {
+			return nil, fmt.Errorf("load atlas: %w", err)
+		}
+		images[i] = AtlasImage{Name: rel, W: w, H: h, Pixels: pix}
+	}
+	a, err := PackAtlas(images, opts)
+	if err != nil {
+		return nil, err
+	}
+	if opts.CachePath != "" {
+		if err := writeAtlasCache(opts.CachePath, key, a); err != nil {
+			return nil, fmt.Errorf("load atlas: cache: %w", err)
+		}
+	}
+	return a, nil
+}
+
+// atlasKey identifies the inputs of LoadAtlas: options, file names, sizes
+// and modification times.
+func atlasKey(relPaths []string, opts AtlasOptions) (string, error) {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d %d %d\n", opts.MaxSize, opts.Padding, opts.Extrude)
+	for _, rel := range relPaths {
+		path := filepath.Join("assets", "textures", rel)
+		fi, err := os.Stat(path)
+		if err != nil {
+			return "", fmt.Errorf("load atlas: %w", err)
+		}
+		fmt.Fprintf(h, "%q %d %d\n", rel, fi.Size(), fi.ModTime().UnixNano())
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+type atlasCache struct {
+	Key     string                 `json:"key"`
+	Pages   int                    `json:"pages"`
+	Regions map[string]AtlasRegion `json:"regions"`
+}
+
+func atlasPagePath(cachePath string, i int) string {
+	return cachePath + "-" + strconv.Itoa(i) + ".png"
+}
+
+// readAtlasCache loads a cached atlas; false when missing, stale or broken.
+func readAtlasCache(cachePath, key string) (*Atlas, bool) {
+	data, err := os.ReadFile(cachePath + ".json")
+	if err != nil {
+		return nil, false
+	}
+	var c atlasCache
+	if err := json.Unmarshal(data, &c); err != nil || c.Key != key {
+		return nil, false
+	}
+	a := &Atlas{Pages: make([]AtlasPage, c.Pages), Regions: c.Regions}
+	for i := range a.Pages {
+		w, h, pix, err := LoadPNGFile(atlasPagePath(cachePath, i))
+		if err != nil {
+			return nil, false
+		}
+		a.Pages[i] = AtlasPage{W: w, H: h, Pixels: pix}
+	}
+	for _, reg := range a.Regions {
+		if reg.Page < 0 || reg.Page >= len(a.Pages) {
+			return nil, false
+		}
+	}
+	return a, true
+}
+
+func writeAtlasCache(cachePath, key string, a *Atlas) error {
+	if err := os.MkdirAll(filepath.Dir(cachePath), 0o755); err != nil {
+		return err
+	}
+	for i, p := range a.Pages {
+		f, err := os.Create(atlasPagePath(cachePath, i))
+		if err != nil {
+			return err
+		}
+		img := &image.RGBA{Pix: p.Pixels, Stride: p.W * 4, Rect: image.Rect(0, 0, p.W, p.H)}
+		err = png.Encode(f, img)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	data, err := json.Marshal(atlasCache{Key: key, Pages: len(a.Pages), Regions: a.Regions})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(cachePath+".json", data, 0o644)
+}
+
+// -------- MaxRects --------
+
+type packRect struct{ x, y, w, h int }
+
+func (r packRect) contains(o packRect) bool {
+	return o.x >= r.x && o.y >= r.y && o.x+o.w <= r.x+r.w && o.y+o.h <= r.y+r.h
+}
+
+func (r packRect) overlaps(o packRect) bool {
+	return r.x < o.x+o.w && o.x < r.x+r.w && r.y < o.y+o.h && o.y < r.y+r.h
+}
+
+// maxRects tracks the maximal free rectangles of a bin.
+type maxRects struct {
+	free         []packRect
+	usedW, usedH int
+}
+
+func newMaxRects(w, h int) *maxRects {
+	return &maxRects{free: []packRect{{0, 0, w, h}}}
+}
+
+// insert places a w x h rectangle where it leaves the shortest leftover
+// side, or reports false when it does not fit.
+func (m *maxRects) insert(w, h int) (packRect, bool) {
+	best, found := packRect{}, false
+	bestShort, bestLong := 0, 0
+	for _, f := range m.free {
+		if w > f.w || h > f.h {
+			continue
+		}
+		short, long := min(f.w-w, f.h-h), max(f.w-w, f.h-h)
+		if !found || short < bestShort || short == bestShort && long < bestLong {
+			best, found = packRect{f.x, f.y, w, h}, true
+			bestShort, bestLong = short, long
+		}
+	}
+	if !found {
+		return packRect{}, false
+	}
+	m.place(best)
+	return best, true
+}
+
+// place splits the free rectangles around r and drops those contained in
+// others.
+func (m *maxRects) place(r packRect) {
+	m.usedW, m.usedH = max(m.usedW, r.x+r.w), max(m.usedH, r.y+r.h)
+
+	free := make([]packRect, 0, len(m.free)+4)
+	for _, f := range m.free {
+		if !f.overlaps(r) {
+			free = append(free, f)
+			continue
+		}
+		if r.x > f.x {
+			free = append(free, packRect{f.x, f.y, r.x - f.x, f.h})
+		}
+		if r.x+r.w < f.x+f.w {
+			free = append(free, packRect{r.x + r.w, f.y, f.x + f.w - r.x - r.w, f.h})
+		}
+		if r.y > f.y {
+			free = append(free, packRect{f.x, f.y, f.w, r.y - f.y})
+		}
+		if r.y+r.h < f.y+f.h {
+			free = append(free, packRect{f.x, r.y + r.h, f.w, f.y + f.h - r.y - r.h})
+		}
+	}
+
+	m.free = m.free[:0]
+	for i, f := range free {
+		redundant := false
+		for j, o := range free {
+			// of two equal rectangles, keep the first
+			if i != j && o.contains(f) && (!f.contains(o) || j < i) {
+				redundant = true
+				break
+			}
+		}
+		if !redundant {
+			m.free = append(m.free, f)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package assets
+
+import (
+	"fmt"
+	"image"
+	"testing"
+)
+
+// testImage returns a w x h image whose every pixel encodes its image index
+// and position, so misplaced or bled pixels are caught.
+func testImage(i, w, h int) AtlasImage {
+	img := AtlasImage{Name: fmt.Sprintf("img%d", i), W: w, H: h, Pixels: make([]byte, w*h*4)}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			p := img.Pixels[(y*w+x)*4:]
+			p[0], p[1], p[2], p[3] = byte(i+1), byte(x), byte(y), 255
+		}
+	}
+	return img
+}
+
+func TestPackAtlas(t *testing.T) {
+	type size struct{ w, h int }
+	tests := []struct {
+		name  string
+		sizes []size
+		opts  AtlasOptions
+		pages int // expected page count, 0 => don't check
+	}{
+		{"single", []size{{10, 7}}, AtlasOptions{MaxSize: 64}, 1},
+		{"padding", []size{{8, 8}, {8, 8}, {5, 12}, {12, 5}, {1, 1}}, AtlasOptions{MaxSize: 64, Padding: 2}, 1},
+		{"extrude", []size{{8, 8}, {8, 8}, {5, 12}, {12, 5}, {1, 1}}, AtlasOptions{MaxSize: 64, Extrude: 2}, 1},
+		{"padding and extrude", []size{{16, 16}, {9, 3}, {3, 9}, {1, 1}, {20, 11}, {7, 7}, {7, 7}}, AtlasOptions{MaxSize: 64, Padding: 1, Extrude: 1}, 1},
+		{"exact fit with padding", []size{{64, 64}}, AtlasOptions{MaxSize: 64, Padding: 2}, 1},
+		{"exact fit with extrusion", []size{{62, 62}}, AtlasOptions{MaxSize: 64, Extrude: 1}, 1},
+		{"overflow", []size{{40, 40}, {40, 40}, {40, 40}, {40, 40}, {40, 40}}, AtlasOptions{MaxSize: 64, Padding: 2, Extrude: 1}, 5},
+		{"overflow mixed", []size{{60, 30}, {60, 30}, {60, 30}, {20, 20}, {4, 4}}, AtlasOptions{MaxSize: 64, Padding: 2}, 2},
+		{"many small", func() []size {
+			s := make([]size, 100)
+			for i := range s {
+				s[i] = size{1 + i%13, 1 + i%7}
+			}
+			return s
+		}(), AtlasOptions{MaxSize: 32, Padding: 1, Extrude: 1}, 0},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			images := make([]AtlasImage, len(tc.sizes))
+			for i, s := range tc.sizes {
+				images[i] = testImage(i, s.w, s.h)
+			}
+			a, err := PackAtlas(images, tc.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tc.pages > 0 && len(a.Pages) != tc.pages {
+				t.Errorf("got %d pages, want %d", len(a.Pages), tc.pages)
+			}
+			for p, page := range a.Pages {
+				if page.W&(page.W-1) != 0 || page.H&(page.H-1) != 0 || page.W > tc.opts.MaxSize || page.H > tc.opts.MaxSize {
+					t.Errorf("page %d is %dx%d, want powers of two up to %d", p, page.W, page.H, tc.opts.MaxSize)
+				}
+			}
+			checkRegions(t, a, images, tc.opts)
+		})
+	}
+}
+
+// checkRegions checks that every image, with its extrusion, lies inside its
+// page at least Padding pixels away from the others, and that its pixels
+// and extruded edges were copied.
+func checkRegions(t *testing.T, a *Atlas, images []AtlasImage, opts AtlasOptions) {
+	t.Helper()
+	ext, pad := opts.Extrude, opts.Padding
+	outer := func(r AtlasRegion) image.Rectangle {
+		return image.Rect(r.X-ext, r.Y-ext, r.X+r.W+ext, r.Y+r.H+ext)
+	}
+	for i, img := range images {
+		r := a.Regions[img.Name]
+		if r.W != img.W || r.H != img.H {
+			t.Fatalf("%s: region %dx%d, want %dx%d", img.Name, r.W, r.H, img.W, img.H)
+		}
+		page := a.Pages[r.Page]
+		o := outer(r)
+		if !o.In(image.Rect(0, 0, page.W, page.H)) {
+			t.Fatalf("%s: %v (extrusion included) outside page %d of %dx%d", img.Name, o, r.Page, page.W, page.H)
+		}
+		for _, other := range images[i+1:] {
+			r2 := a.Regions[other.Name]
+			if r2.Page == r.Page && o.Inset(-pad).Overlaps(outer(r2)) {
+				t.Fatalf("%s at %v and %s at %v are closer than the padding %d", img.Name, o, other.Name, outer(r2), pad)
+			}
+		}
+
+		// every pixel of the outer rect holds the nearest image pixel
+		for y := o.Min.Y; y < o.Max.Y; y++ {
+			for x := o.Min.X; x < o.Max.X; x++ {
+				sx, sy := min(max(x-r.X, 0), img.W-1), min(max(y-r.Y, 0), img.H-1)
+				got := page.Pixels[(y*page.W+x)*4:][:4]
+				want := img.Pixels[(sy*img.W+sx)*4:][:4]
+				if string(got) != string(want) {
+					t.Fatalf("%s: page pixel (%d,%d) = %v, want %v", img.Name, x, y, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestPackAtlasErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		images []AtlasImage
+		opts   AtlasOptions
+	}{
+		{"too large", []AtlasImage{testImage(0, 65, 8)}, AtlasOptions{MaxSize: 64}},
+		{"too large with extrusion", []AtlasImage{testImage(0, 63, 8)}, AtlasOptions{MaxSize: 64, Extrude: 1}},
+		{"duplicate name", []AtlasImage{testImage(0, 4, 4), testImage(0, 4, 4)}, AtlasOptions{}},
+		{"short pixels", []AtlasImage{{Name: "a", W: 4, H: 4, Pixels: make([]byte, 10)}}, AtlasOptions{}},
+		{"empty", []AtlasImage{{Name: "a"}}, AtlasOptions{}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := PackAtlas(tc.images, tc.opts); err == nil {
+				t.Error("no error")
+			}
+		})
+	}
+}
@@ -12,7 +12,11 @@ import (
 // LoadPNG returns width, height, and tightly packed RGBA8 pixels (row-major, top-left origin).
 // We then flip vertically to match OpenGL's bottom-left origin.
 func LoadPNG(relPath string) (w, h int, rgba []byte, err error) {
-	path := filepath.Join("assets", "textures", relPath)
+	return LoadPNGFile(filepath.Join("assets", "textures", relPath))
+}
+
+// LoadPNGFile is LoadPNG for a path that is not under assets/textures.
+func LoadPNGFile(path string) (w, h int, rgba []byte, err error) {
 	f, err := os.Open(path)
 	if err != nil {
 		return 0, 0, nil, fmt.Errorf("open %q: %w", path, err)
@@ -4,18 +4,190 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/hubastard/grove/engine/core"
 )
 
-// LoadShader reads a GLSL file into a null-terminated string for OpenGL.
-func LoadShader(name string) (string, error) {
-	path := filepath.Join("assets", "shaders", name)
-	b, err := os.ReadFile(path)
+var shaderDir = filepath.Join("assets", "shaders")
+
+// LoadShader reads a GLSL file into a null-terminated string for OpenGL,
+// resolving #include directives and injecting defines (see LoadShaderSource).
+func LoadShader(name string, defines ...string) (string, error) {
+	src, err := LoadShaderSource(name, defines...)
+	return src.Code, err
+}
+
+// LoadShaderSource preprocesses a GLSL file from assets/shaders:
+//
+//   - `#include "common.glsl"` pastes another file from assets/shaders
+//     (files with `#pragma once` are pasted only once);
+//   - defines ("ALPHA_TEST", "MAX_TEXTURES=32") are inserted after #version.
+//
+// The code carries "#line N S" directives and Files maps each S back to its
+// file, so compile errors point at the original file and line.
+func LoadShaderSource(name string, defines ...string) (core.ShaderSource, error) {
+	head, err := defineLines(defines)
 	if err != nil {
-		return "", fmt.Errorf("load shader %q: %w", name, err)
+		return core.ShaderSource{}, fmt.Errorf("load shader %q: %w", name, err)
+	}
+
+	p := &preprocessor{index: map[string]int{}, once: map[string]bool{}}
+	if err := p.include(filepath.ToSlash(name), true); err != nil {
+		return core.ShaderSource{}, fmt.Errorf("load shader %q: %w", name, err)
 	}
-	// Ensure null termination for gl.Str
-	if len(b) == 0 || b[len(b)-1] != 0 {
-		b = append(b, 0)
+	if p.version == "" {
+		return core.ShaderSource{}, fmt.Errorf("load shader %q: missing #version", name)
+	}
+
+	code := p.version + "\n" + head + p.out.String() + "\x00"
+	return core.ShaderSource{Code: code, Files: p.files}, nil
+}
+
+type preprocessor struct {
+	files   []string // GLSL source-string number -> file name
+	index   map[string]int
+	once    map[string]bool
+	stack   []string // include chain, for cycle errors
+	version string   // #version line of the root file
+	out     strings.Builder
+}
+
+// includeRe matches `#include "file"`, optionally followed by a // or a
+// single-line /* */ comment.
+var includeRe = regexp.MustCompile(`^#\s*include\s+"([^"]+)"\s*(//.*|/\*.*\*/)?$`)
+
+func (p *preprocessor) include(name string, root bool) error {
+	if slices.Contains(p.stack, name) {
+		return fmt.Errorf("include cycle: %s -> %s", strings.Join(p.stack, " -> "), name)
+	}
+	if p.once[name] {
+		return nil
+	}
+	data, err := os.ReadFile(filepath.Join(shaderDir, filepath.FromSlash(name)))
+	if err != nil {
+		return err
+	}
+
+	num, ok := p.index[name]
+	if !ok {
+		num = len(p.files)
+		p.index[name] = num
+		p.files = append(p.files, name)
+	}
+	p.stack = append(p.stack, name)
+	defer func() { p.stack = p.stack[:len(p.stack)-1] }()
+
+	fmt.Fprintf(&p.out, "#line 1 %d\n", num)
+	text := strings.ReplaceAll(strings.TrimRight(string(data), "\x00"), "\r\n", "\n")
+	for i, line := range strings.Split(text, "\n") {
+		t := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(t, "#version"):
+			if !root {
+				return fmt.Errorf("%s:%d: #version in an included file", name, i+1)
+			}
+			p.version = t
+			p.out.WriteByte('\n') // hoisted above the defines; keep line count
+		case t == "#pragma once":
+			p.once[name] = true
+			p.out.WriteByte('\n')
+		case strings.HasPrefix(t, "#include"):
+			m := includeRe.FindStringSubmatch(t)
+			if m == nil {
+				return fmt.Errorf("%s:%d: malformed #include (want #include \"file\")", name, i+1)
+			}
+			if err := p.include(m[1], false); err != nil {
+				return fmt.Errorf("%s:%d: %w", name, i+1, err)
+			}
+			fmt.Fprintf(&p.out, "#line %d %d\n", i+2, num)
+		default:
+			p.out.WriteString(line)
+			p.out.WriteByte('\n')
+		}
+	}
+	return nil
+}
+
+var defineRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// defineLines turns "NAME" / "NAME=VALUE" into #define lines.
+func defineLines(defines []string) (string, error) {
+	var b strings.Builder
+	for _, d := range defines {
+		name, value, _ := strings.Cut(d, "=")
+		name = strings.TrimSpace(name)
+		if !defineRe.MatchString(name) {
+			return "", fmt.Errorf("invalid define %q", d)
+		}
+		b.WriteString("#define " + name)
+		if v := strings.TrimSpace(value); v != "" {
+			b.WriteString(" " + v)
+		}
+		b.WriteByte('\n')
+	}
+	return b.String(), nil
+}
+
+// variantKey normalizes a define set so its order doesn't matter.
+func variantKey(defines []string) string {
+	ds := slices.Clone(defines)
+	for i, d := range ds {
+		name, value, _ := strings.Cut(d, "=")
+		ds[i] = strings.TrimSpace(name) + "=" + strings.TrimSpace(value)
+	}
+	slices.Sort(ds)
+	return strings.Join(ds, ";")
+}
+
+// -------- Shader variants --------
+
+// ShaderVariants builds pipelines from one vertex/fragment pair, keyed by
+// the defines they were compiled with. Each variant is compiled once.
+type ShaderVariants struct {
+	r          core.Renderer
+	vert, frag string
+	desc       core.PipelineDesc // state, layout and label shared by all variants
+
+	cache map[string]core.Pipeline // only touched on the render thread
+}
+
+// NewShaderVariants prepares variants of vert+frag (file names in
+// assets/shaders). desc supplies everything except the sources.
+func NewShaderVariants(r core.Renderer, vert, frag string, desc core.PipelineDesc) *ShaderVariants {
+	return &ShaderVariants{r: r, vert: vert, frag: frag, desc: desc, cache: map[string]core.Pipeline{}}
+}
+
+// Get returns the pipeline compiled with defines, building it on first use.
+// Must be called on the render thread, like CreatePipeline; the cache is not
+// locked.
+func (v *ShaderVariants) Get(defines ...string) (core.Pipeline, error) {
+	key := variantKey(defines)
+	if p, ok := v.cache[key]; ok {
+		return p, nil
+	}
+
+	vs, err := LoadShaderSource(v.vert, defines...)
+	if err != nil {
+		return nil, err
+	}
+	fs, err := LoadShaderSource(v.frag, defines...)
+	if err != nil {
+		return nil, err
+	}
+	desc := v.desc
+	desc.VertexSource, desc.VertexFiles = vs.Code, vs.Files
+	desc.FragmentSource, desc.FragmentFiles = fs.Code, fs.Files
+	if desc.Label != "" && key != "" {
+		desc.Label += " [" + key + "]"
+	}
+
+	p, err := v.r.CreatePipeline(desc)
+	if err != nil {
+		return nil, err
 	}
-	return string(b), nil
+	v.cache[key] = p
+	return p, nil
 }
@@ -0,0 +1,230 @@
+package assets
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/hubastard/grove/engine/core"
+)
+
+// useShaderDir points the loader at a temp directory holding files.
+func useShaderDir(t *testing.T, files map[string]string) {
+	t.Helper()
+	dir := t.TempDir()
+	for name, src := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := shaderDir
+	shaderDir = dir
+	t.Cleanup(func() { shaderDir = old })
+}
+
+// origin returns "file:line" for the first output line equal to text, the
+// way a GLSL compiler numbers it after the #line directives.
+func origin(t *testing.T, src core.ShaderSource, text string) string {
+	t.Helper()
+	file, line := 0, 1
+	for _, l := range strings.Split(strings.TrimSuffix(src.Code, "\x00"), "\n") {
+		if rest, ok := strings.CutPrefix(l, "#line "); ok {
+			f := strings.Fields(rest)
+			line, _ = strconv.Atoi(f[0])
+			if len(f) > 1 {
+				file, _ = strconv.Atoi(f[1])
+			}
+			continue
+		}
+		if l == text {
+			return src.Files[file] + ":" + strconv.Itoa(line)
+		}
+		line++
+	}
+	t.Fatalf("%q not in\n%s", text, src.Code)
+	return ""
+}
+
+func TestLoadShaderSourceLines(t *testing.T) {
+	useShaderDir(t, map[string]string{
+		"main.vert": "#version 330 core\n" +
+			"#include \"a.glsl\"\n" +
+			"void main() {}\n",
+		"a.glsl": "#pragma once\n" +
+			"// a\n" +
+			"#include \"lib/b.glsl\" /* nested */\n" +
+			"float a;\n",
+		"lib/b.glsl": "float b;\n",
+	})
+	src, err := LoadShaderSource("main.vert", "FOO")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for text, want := range map[string]string{
+		"float b;":       "lib/b.glsl:1",
+		"float a;":       "a.glsl:4",
+		"void main() {}": "main.vert:3",
+		"#define FOO":    "main.vert:2", // before the first #line
+	} {
+		if got := origin(t, src, text); got != want {
+			t.Errorf("%q at %s, want %s", text, got, want)
+		}
+	}
+}
+
+func TestLoadShaderSourceDefines(t *testing.T) {
+	useShaderDir(t, map[string]string{
+		"s.frag": "// header\n#version 330 core\nout vec4 c;\n",
+	})
+	src, err := LoadShaderSource("s.frag", "ALPHA_TEST", " MAX = 32 ", "EMPTY=")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "#version 330 core\n" +
+		"#define ALPHA_TEST\n" +
+		"#define MAX 32\n" +
+		"#define EMPTY\n" +
+		"#line 1 0\n" +
+		"// header\n" +
+		"\n" + // the hoisted #version
+		"out vec4 c;\n" +
+		"\n\x00"
+	if src.Code != want {
+		t.Errorf("got\n%q\nwant\n%q", src.Code, want)
+	}
+
+	for _, bad := range []string{"", "1X", "A-B", "A B=1"} {
+		if _, err := LoadShaderSource("s.frag", bad); err == nil {
+			t.Errorf("define %q: no error", bad)
+		}
+	}
+}
+
+func TestLoadShaderSourceOnce(t *testing.T) {
+	useShaderDir(t, map[string]string{
+		"main.vert": "#version 330 core\n" +
+			"#include \"once.glsl\"\n" +
+			"#include \"twice.glsl\"\n" +
+			"#include \"once.glsl\"\n" +
+			"#include \"twice.glsl\"\n",
+		"once.glsl":  "#pragma once\nfloat once;\n",
+		"twice.glsl": "float twice;\n",
+	})
+	src, err := LoadShaderSource("main.vert")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(src.Code, "float once;"); n != 1 {
+		t.Errorf("#pragma once file pasted %d times", n)
+	}
+	if n := strings.Count(src.Code, "float twice;"); n != 2 {
+		t.Errorf("plain file pasted %d times, want 2", n)
+	}
+	if want := []string{"main.vert", "once.glsl", "twice.glsl"}; strings.Join(src.Files, ",") != strings.Join(want, ",") {
+		t.Errorf("files %v, want %v", src.Files, want)
+	}
+}
+
+func TestLoadShaderSourceErrors(t *testing.T) {
+	useShaderDir(t, map[string]string{
+		"cycle.vert":     "#version 330 core\n#include \"a.glsl\"\n",
+		"a.glsl":         "#pragma once\n#include \"b.glsl\"\n",
+		"b.glsl":         "\n\n#include \"a.glsl\"\n",
+		"noversion.vert": "void main() {}\n",
+		"nested.vert":    "#version 330 core\n#include \"version.glsl\"\n",
+		"version.glsl":   "#version 330 core\n",
+		"bad.vert":       "#version 330 core\n#include <common.glsl>\n",
+		"trailing.vert":  "#version 330 core\n#include \"a.glsl\" float x;\n",
+		"missing.vert":   "#version 330 core\n#include \"nope.glsl\"\n",
+	})
+	tests := []struct {
+		file string
+		want string
+	}{
+		{"cycle.vert", "b.glsl:3: include cycle: cycle.vert -> a.glsl -> b.glsl -> a.glsl"},
+		{"noversion.vert", "missing #version"},
+		{"nested.vert", "version.glsl:1: #version in an included file"},
+		{"bad.vert", "bad.vert:2: malformed #include"},
+		{"trailing.vert", "trailing.vert:2: malformed #include"},
+		{"missing.vert", "missing.vert:2: "},
+	}
+	for _, tc := range tests {
+		t.Run(tc.file, func(t *testing.T) {
+			_, err := LoadShaderSource(tc.file)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("error %v, want it to contain %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestVariantKey(t *testing.T) {
+	tests := []struct {
+		a, b []string
+		same bool
+	}{
+		{[]string{"A", "B=1"}, []string{"B=1", "A"}, true},
+		{[]string{" A ", "B = 1"}, []string{"A=", "B=1"}, true},
+		{nil, []string{}, true},
+		{[]string{"A"}, []string{"B"}, false},
+		{[]string{"N=1"}, []string{"N=2"}, false},
+		{[]string{"A", "B"}, []string{"A"}, false},
+	}
+	for _, tc := range tests {
+		if ka, kb := variantKey(tc.a), variantKey(tc.b); (ka == kb) != tc.same {
+			t.Errorf("variantKey(%q) = %q, variantKey(%q) = %q, same = %v", tc.a, ka, tc.b, kb, tc.same)
+		}
+	}
+}
+
+type testPipeline struct{ label string }
+
+func (testPipeline) IsPipeline() {}
+
+// pipelineCounter is a Renderer that only builds pipelines.
+type pipelineCounter struct {
+	core.Renderer
+	created []core.PipelineDesc
+}
+
+func (r *pipelineCounter) CreatePipeline(desc core.PipelineDesc) (core.Pipeline, error) {
+	r.created = append(r.created, desc)
+	return &testPipeline{desc.Label}, nil
+}
+
+func TestShaderVariants(t *testing.T) {
+	useShaderDir(t, map[string]string{
+		"s.vert": "#version 330 core\nvoid main() {}\n",
+		"s.frag": "#version 330 core\nvoid main() {}\n",
+	})
+	r := &pipelineCounter{}
+	v := NewShaderVariants(r, "s.vert", "s.frag", core.PipelineDesc{Label: "s"})
+
+	p1, err := v.Get("A", "B=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2, err := v.Get("B = 1", "A")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p1 != p2 || len(r.created) != 1 {
+		t.Errorf("same defines in another order built %d pipelines", len(r.created))
+	}
+	if got := r.created[0]; got.Label != "s [A=;B=1]" || !strings.Contains(got.FragmentSource, "#define B 1\n") {
+		t.Errorf("variant label %q, fragment source %q", got.Label, got.FragmentSource)
+	}
+
+	if p3, _ := v.Get(); p3 == p1 || len(r.created) != 2 {
+		t.Error("no defines reused the A;B=1 variant")
+	}
+	if got := r.created[1].Label; got != "s" {
+		t.Errorf("default variant label %q, want s", got)
+	}
+}
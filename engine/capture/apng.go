@@ -0,0 +1,152 @@
+package capture
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var pngEnc = png.Encoder{CompressionLevel: png.BestSpeed}
+
+// -------- PNG sequence --------
+
+type pngSeqEncoder struct {
+	dir string
+	n   int
+}
+
+func (p *pngSeqEncoder) add(img *image.RGBA, _ time.Duration) error {
+	f, err := os.Create(filepath.Join(p.dir, fmt.Sprintf("frame_%05d.png", p.n)))
+	if err != nil {
+		return err
+	}
+	p.n++
+	if err := pngEnc.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (p *pngSeqEncoder) finish(string) error { return nil }
+
+// -------- APNG --------
+
+// apngEncoder encodes each frame as a regular PNG and keeps its IHDR and
+// compressed image data; finish stitches them into an animated PNG (the
+// frame count has to be written before the first frame).
+type apngEncoder struct {
+	ihdr   []byte
+	frames []apngFrame
+}
+
+type apngFrame struct {
+	data  [][]byte // IDAT payloads
+	delay time.Duration
+}
+
+func (a *apngEncoder) add(img *image.RGBA, delay time.Duration) error {
+	var buf bytes.Buffer
+	if err := pngEnc.Encode(&buf, img); err != nil {
+		return err
+	}
+
+	fr := apngFrame{delay: delay}
+	b := buf.Bytes()[8:] // skip signature
+	for len(b) >= 12 {
+		n := binary.BigEndian.Uint32(b)
+		typ := string(b[4:8])
+		data := b[8 : 8+n]
+		switch typ {
+		case "IHDR":
+			if a.ihdr == nil {
+				a.ihdr = bytes.Clone(data)
+			} else if !bytes.Equal(a.ihdr, data) {
+				return fmt.Errorf("apng: frame header differs from the first frame")
+			}
+		case "IDAT":
+			fr.data = append(fr.data, bytes.Clone(data))
+		}
+		b = b[12+n:]
+	}
+	a.frames = append(a.frames, fr)
+	return nil
+}
+
+func (a *apngEncoder) finish(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := a.write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (a *apngEncoder) write(w io.Writer) error {
+	cw := &chunkWriter{w: w}
+	cw.raw([]byte("\x89PNG\r\n\x1a\n"))
+	cw.chunk("IHDR", a.ihdr)
+
+	cw.chunk("acTL", be32(uint32(len(a.frames)), 0)) // loop forever
+
+	width, height := binary.BigEndian.Uint32(a.ihdr[0:]), binary.BigEndian.Uint32(a.ihdr[4:])
+	seq := uint32(0)
+	for i, fr := range a.frames {
+		ms := uint32(min(max(fr.delay.Milliseconds(), 1), 0xffff))
+		fctl := be32(seq, width, height, 0, 0)
+		fctl = append(fctl, byte(ms>>8), byte(ms), 0x03, 0xe8) // delay ms/1000
+		fctl = append(fctl, 0, 0)                              // dispose none, blend source
+		cw.chunk("fcTL", fctl)
+		seq++
+
+		for _, d := range fr.data {
+			if i == 0 {
+				cw.chunk("IDAT", d) // first frame doubles as the static image
+				continue
+			}
+			cw.chunk("fdAT", append(be32(seq), d...))
+			seq++
+		}
+	}
+	cw.chunk("IEND", nil)
+	return cw.err
+}
+
+type chunkWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (c *chunkWriter) raw(b []byte) {
+	if c.err == nil {
+		_, c.err = c.w.Write(b)
+	}
+}
+
+func (c *chunkWriter) chunk(typ string, data []byte) {
+	crc := crc32.NewIEEE()
+	crc.Write([]byte(typ))
+	crc.Write(data)
+	c.raw(be32(uint32(len(data))))
+	c.raw([]byte(typ))
+	c.raw(data)
+	c.raw(be32(crc.Sum32()))
+}
+
+func be32(vs ...uint32) []byte {
+	b := make([]byte, 0, 4*len(vs))
+	for _, v := range vs {
+		b = binary.BigEndian.AppendUint32(b, v)
+	}
+	return b
+}
@@ -0,0 +1,196 @@
+package capture
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"testing"
+	"time"
+)
+
+// testFrame returns a w x h image filled with a pattern derived from seed.
+func testFrame(w, h int, seed byte) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			p := img.Pix[y*img.Stride+x*4:]
+			p[0], p[1], p[2], p[3] = byte(x*16)+seed, byte(y*16), seed, 255
+		}
+	}
+	return img
+}
+
+type pngChunk struct {
+	typ  string
+	data []byte
+}
+
+// readChunks splits a PNG stream into chunks, checking the signature and
+// every CRC.
+func readChunks(t *testing.T, b []byte) []pngChunk {
+	t.Helper()
+	if !bytes.HasPrefix(b, []byte("\x89PNG\r\n\x1a\n")) {
+		t.Fatalf("missing PNG signature: % x", b[:min(8, len(b))])
+	}
+	b = b[8:]
+	var chunks []pngChunk
+	for len(b) > 0 {
+		if len(b) < 12 {
+			t.Fatalf("truncated chunk: % x", b)
+		}
+		n := binary.BigEndian.Uint32(b)
+		typ, data := string(b[4:8]), b[8:8+n]
+		if got, want := binary.BigEndian.Uint32(b[8+n:]), crc32.ChecksumIEEE(b[4:8+n]); got != want {
+			t.Fatalf("%s chunk %d: crc %08x, want %08x", typ, len(chunks), got, want)
+		}
+		chunks = append(chunks, pngChunk{typ, data})
+		b = b[12+n:]
+	}
+	return chunks
+}
+
+func TestChunkWriter(t *testing.T) {
+	var buf bytes.Buffer
+	cw := &chunkWriter{w: &buf}
+	cw.chunk("IEND", nil)
+	cw.chunk("tEXt", []byte("a\x00b"))
+	want := []byte{
+		0, 0, 0, 0, 'I', 'E', 'N', 'D', 0xae, 0x42, 0x60, 0x82,
+		0, 0, 0, 3, 't', 'E', 'X', 't', 'a', 0, 'b', 0xdc, 0x49, 0xa2, 0x3b,
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("got  % x\nwant % x", buf.Bytes(), want)
+	}
+}
+
+func TestAPNG(t *testing.T) {
+	frames := []*image.RGBA{testFrame(16, 8, 0), testFrame(16, 8, 100), testFrame(16, 8, 200)}
+	delays := []time.Duration{40 * time.Millisecond, 0, 2 * time.Minute}
+
+	var a apngEncoder
+	for i, f := range frames {
+		if err := a.add(f, delays[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var buf bytes.Buffer
+	if err := a.write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	chunks := readChunks(t, buf.Bytes())
+
+	// IHDR, acTL, then per frame fcTL + image data, then IEND
+	if chunks[0].typ != "IHDR" || chunks[1].typ != "acTL" || chunks[len(chunks)-1].typ != "IEND" {
+		t.Fatalf("chunk order: %v", chunkTypes(chunks))
+	}
+	if got := binary.BigEndian.Uint32(chunks[1].data); got != uint32(len(frames)) {
+		t.Errorf("acTL: %d frames, want %d", got, len(frames))
+	}
+	if got := binary.BigEndian.Uint32(chunks[1].data[4:]); got != 0 {
+		t.Errorf("acTL: %d plays, want 0 (loop)", got)
+	}
+
+	var (
+		seq     uint32
+		fctls   int
+		frameAt = -1
+		data    = make([][]byte, len(frames)) // zlib stream of each frame
+	)
+	for _, c := range chunks[2 : len(chunks)-1] {
+		switch c.typ {
+		case "fcTL":
+			frameAt++
+			if got := binary.BigEndian.Uint32(c.data); got != seq {
+				t.Errorf("fcTL of frame %d: sequence %d, want %d", frameAt, got, seq)
+			}
+			seq++
+			fctls++
+			w, h := binary.BigEndian.Uint32(c.data[4:]), binary.BigEndian.Uint32(c.data[8:])
+			if w != 16 || h != 8 {
+				t.Errorf("fcTL of frame %d: %dx%d, want 16x8", frameAt, w, h)
+			}
+			num, den := binary.BigEndian.Uint16(c.data[20:]), binary.BigEndian.Uint16(c.data[22:])
+			wantMS := []uint16{40, 1, 0xffff}[frameAt] // at least 1ms, clamped to 16 bits
+			if num != wantMS || den != 1000 {
+				t.Errorf("fcTL of frame %d: delay %d/%d, want %d/1000", frameAt, num, den, wantMS)
+			}
+		case "IDAT":
+			if frameAt != 0 {
+				t.Errorf("IDAT in frame %d, want only in the first", frameAt)
+			}
+			data[frameAt] = append(data[frameAt], c.data...)
+		case "fdAT":
+			if frameAt == 0 {
+				t.Error("fdAT in the first frame")
+			}
+			if got := binary.BigEndian.Uint32(c.data); got != seq {
+				t.Errorf("fdAT of frame %d: sequence %d, want %d", frameAt, got, seq)
+			}
+			seq++
+			data[frameAt] = append(data[frameAt], c.data[4:]...)
+		default:
+			t.Errorf("unexpected %s chunk", c.typ)
+		}
+	}
+	if fctls != len(frames) {
+		t.Fatalf("%d fcTL chunks, want %d", fctls, len(frames))
+	}
+
+	// a plain decoder sees the first frame
+	img, err := png.Decode(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkPixels(t, "static image", img, frames[0])
+
+	// every frame's data decodes back to it
+	for i, d := range data {
+		var b bytes.Buffer
+		cw := &chunkWriter{w: &b}
+		cw.raw([]byte("\x89PNG\r\n\x1a\n"))
+		cw.chunk("IHDR", chunks[0].data)
+		cw.chunk("IDAT", d)
+		cw.chunk("IEND", nil)
+		img, err := png.Decode(&b)
+		if err != nil {
+			t.Fatalf("frame %d: %v", i, err)
+		}
+		checkPixels(t, "frame", img, frames[i])
+	}
+}
+
+func TestAPNGSizeChange(t *testing.T) {
+	var a apngEncoder
+	if err := a.add(testFrame(4, 4, 0), time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.add(testFrame(8, 4, 0), time.Millisecond); err == nil {
+		t.Error("no error for a frame of another size")
+	}
+}
+
+func chunkTypes(chunks []pngChunk) []string {
+	types := make([]string, len(chunks))
+	for i, c := range chunks {
+		types[i] = c.typ
+	}
+	return types
+}
+
+func checkPixels(t *testing.T, what string, got image.Image, want *image.RGBA) {
+	t.Helper()
+	if got.Bounds() != want.Rect {
+		t.Fatalf("%s: bounds %v, want %v", what, got.Bounds(), want.Rect)
+	}
+	for y := 0; y < want.Rect.Dy(); y++ {
+		for x := 0; x < want.Rect.Dx(); x++ {
+			r, g, b, a := got.At(x, y).RGBA()
+			w := want.RGBAAt(x, y)
+			if uint8(r>>8) != w.R || uint8(g>>8) != w.G || uint8(b>>8) != w.B || uint8(a>>8) != w.A {
+				t.Fatalf("%s: pixel (%d,%d) = %v, want %v", what, x, y, got.At(x, y), w)
+			}
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package capture
+
+import (
+	"image"
+	"image/color"
+	"image/gif"
+	"os"
+	"slices"
+	"time"
+)
+
+// gifEncoder quantizes each frame to its own median-cut palette with
+// Floyd-Steinberg dithering. GIF needs all frames up front, so the paletted
+// frames (1 byte per pixel) are kept until finish.
+type gifEncoder struct {
+	anim  gif.GIF
+	carry time.Duration // sub-centisecond remainder of frame delays
+}
+
+func (g *gifEncoder) add(img *image.RGBA, delay time.Duration) error {
+	pal := medianCut(img, 256)
+	g.anim.Image = append(g.anim.Image, dither(img, pal))
+
+	delay += g.carry
+	cs := int(delay / (10 * time.Millisecond))
+	cs = max(cs, 2) // most viewers clamp shorter delays to 10cs
+	g.carry = delay - time.Duration(cs)*10*time.Millisecond
+	g.anim.Delay = append(g.anim.Delay, cs)
+	return nil
+}
+
+func (g *gifEncoder) finish(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := gif.EncodeAll(f, &g.anim); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// -------- Median-cut quantization --------
+
+type colorBox struct {
+	px []uint32 // 0xRRGGBB
+}
+
+// medianCut builds a palette of at most n colors from a sample of img.
+func medianCut(img *image.RGBA, n int) color.Palette {
+	const maxSamples = 1 << 16
+	total := len(img.Pix) / 4
+	step := max(1, total/maxSamples)
+
+	px := make([]uint32, 0, total/step+1)
+	for i := 0; i < total; i += step {
+		p := img.Pix[i*4:]
+		px = append(px, uint32(p[0])<<16|uint32(p[1])<<8|uint32(p[2]))
+	}
+
+	boxes := []colorBox{{px: px}}
+	for len(boxes) < n {
+		// split the box with the widest channel range
+		best, bestCh, bestRange := -1, 0, 0
+		for i, b := range boxes {
+			if len(b.px) < 2 {
+				continue
+			}
+			ch, r := b.widest()
+			if r > bestRange {
+				best, bestCh, bestRange = i, ch, r
+			}
+		}
+		if best < 0 {
+			break // every box is a single color
+		}
+
+		b := boxes[best]
+		shift := uint(16 - 8*bestCh)
+		slices.SortFunc(b.px, func(x, y uint32) int {
+			return int(x>>shift&0xff) - int(y>>shift&0xff)
+		})
+		mid := len(b.px) / 2
+		boxes[best] = colorBox{px: b.px[:mid]}
+		boxes = append(boxes, colorBox{px: b.px[mid:]})
+	}
+
+	pal := make(color.Palette, 0, len(boxes))
+	for _, b := range boxes {
+		if len(b.px) == 0 {
+			continue
+		}
+		var r, g, bl int
+		for _, c := range b.px {
+			r += int(c >> 16 & 0xff)
+			g += int(c >> 8 & 0xff)
+			bl += int(c & 0xff)
+		}
+		k := len(b.px)
+		pal = append(pal, color.RGBA{uint8(r / k), uint8(g / k), uint8(bl / k), 0xff})
+	}
+	return pal
+}
+
+// widest returns the channel (0=R, 1=G, 2=B) with the largest range.
+func (b colorBox) widest() (ch, rng int) {
+	lo := [3]int{255, 255, 255}
+	var hi [3]int
+	for _, c := range b.px {
+		v := [3]int{int(c >> 16 & 0xff), int(c >> 8 & 0xff), int(c & 0xff)}
+		for i := range v {
+			lo[i] = min(lo[i], v[i])
+			hi[i] = max(hi[i], v[i])
+		}
+	}
+	for i := range lo {
+		if hi[i]-lo[i] > rng {
+			ch, rng = i, hi[i]-lo[i]
+		}
+	}
+	return ch, rng
+}
+
+// -------- Dithering --------
+
+// dither maps img onto pal with Floyd-Steinberg error diffusion. Nearest
+// colors are cached on a 5-bit-per-channel grid, which is plenty after
+// adding the diffused error.
+func dither(img *image.RGBA, pal color.Palette) *image.Paletted {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	dst := image.NewPaletted(image.Rect(0, 0, w, h), pal)
+
+	rgb := make([][3]int32, len(pal))
+	for i, c := range pal {
+		cc := c.(color.RGBA)
+		rgb[i] = [3]int32{int32(cc.R), int32(cc.G), int32(cc.B)}
+	}
+	cache := make([]int16, 1<<15)
+	for i := range cache {
+		cache[i] = -1
+	}
+	nearest := func(r, g, b int32) int {
+		key := r>>3<<10 | g>>3<<5 | b>>3
+		if idx := cache[key]; idx >= 0 {
+			return int(idx)
+		}
+		best, bestD := 0, int32(1<<30)
+		for i, c := range rgb {
+			dr, dg, db := r-c[0], g-c[1], b-c[2]
+			if d := dr*dr + dg*dg + db*db; d < bestD {
+				best, bestD = i, d
+			}
+		}
+		cache[key] = int16(best)
+		return best
+	}
+
+	// error rows for the current and next line, 1 pixel of padding each side
+	cur := make([][3]int32, w+2)
+	next := make([][3]int32, w+2)
+	for y := 0; y < h; y++ {
+		row := img.Pix[y*img.Stride:]
+		for x := 0; x < w; x++ {
+			var c [3]int32
+			for k := 0; k < 3; k++ {
+				c[k] = min(max(int32(row[x*4+k])+cur[x+1][k]/16, 0), 255)
+			}
+			idx := nearest(c[0], c[1], c[2])
+			dst.Pix[y*dst.Stride+x] = uint8(idx)
+
+			for k := 0; k < 3; k++ {
+				e := c[k] - rgb[idx][k]
+				cur[x+2][k] += e * 7
+				next[x][k] += e * 3
+				next[x+1][k] += e * 5
+				next[x+2][k] += e
+			}
+		}
+		cur, next = next, cur
+		clear(next)
+	}
+	return dst
+}
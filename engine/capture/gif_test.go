@@ -0,0 +1,123 @@
+package capture
+
+import (
+	"image"
+	"image/color"
+	"slices"
+	"testing"
+	"time"
+)
+
+func solidRows(w int, rows ...color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, len(rows)))
+	for y, c := range rows {
+		for x := 0; x < w; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func paletteSet(pal color.Palette) []color.RGBA {
+	out := make([]color.RGBA, len(pal))
+	for i, c := range pal {
+		out[i] = c.(color.RGBA)
+	}
+	slices.SortFunc(out, func(a, b color.RGBA) int {
+		return int(a.R)<<16 + int(a.G)<<8 + int(a.B) - (int(b.R)<<16 + int(b.G)<<8 + int(b.B))
+	})
+	return out
+}
+
+func TestMedianCut(t *testing.T) {
+	red, green, blue, white := color.RGBA{255, 0, 0, 255}, color.RGBA{0, 255, 0, 255}, color.RGBA{0, 0, 255, 255}, color.RGBA{255, 255, 255, 255}
+	gray := func(v uint8) color.RGBA { return color.RGBA{v, v, v, 255} }
+
+	tests := []struct {
+		name string
+		img  *image.RGBA
+		n    int
+		want []color.RGBA // sorted by RGB
+	}{
+		{"single color", solidRows(4, red, red), 256, []color.RGBA{red}},
+		{"fewer colors than n", solidRows(4, red, green, blue, white), 256, []color.RGBA{blue, green, red, white}},
+		{"exactly n colors", solidRows(4, red, green, blue, white), 4, []color.RGBA{blue, green, red, white}},
+		// equal halves of the widest channel average to their means
+		{"split in two", solidRows(2, gray(0), gray(10), gray(200), gray(210)), 2, []color.RGBA{gray(5), gray(205)}},
+		{"split widest channel", solidRows(2,
+			color.RGBA{0, 100, 100, 255}, color.RGBA{250, 100, 100, 255}, // red spans 250
+			color.RGBA{0, 110, 100, 255}, color.RGBA{250, 110, 100, 255}), 2,
+			[]color.RGBA{{0, 105, 100, 255}, {250, 105, 100, 255}}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			pal := medianCut(tc.img, tc.n)
+			if got := paletteSet(pal); !slices.Equal(got, tc.want) {
+				t.Errorf("palette %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMedianCutLimit(t *testing.T) {
+	// 64x64 image with 4096 distinct colors
+	img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			img.SetRGBA(x, y, color.RGBA{uint8(x * 4), uint8(y * 4), uint8((x ^ y) * 4), 255})
+		}
+	}
+	for _, n := range []int{1, 2, 16, 256} {
+		if pal := medianCut(img, n); len(pal) != n {
+			t.Errorf("n=%d: %d colors", n, len(pal))
+		}
+	}
+}
+
+func TestDither(t *testing.T) {
+	t.Run("exact colors", func(t *testing.T) {
+		red, blue := color.RGBA{255, 0, 0, 255}, color.RGBA{0, 0, 255, 255}
+		img := solidRows(5, red, blue, red)
+		pal := color.Palette{blue, red}
+		p := dither(img, pal)
+		for y, want := range []uint8{1, 0, 1} {
+			for x := 0; x < 5; x++ {
+				if got := p.ColorIndexAt(x, y); got != want {
+					t.Fatalf("pixel (%d,%d) = %d, want %d", x, y, got, want)
+				}
+			}
+		}
+	})
+
+	t.Run("keeps the average", func(t *testing.T) {
+		// 25% gray from black and white: a quarter of the pixels end up white
+		gray := color.RGBA{64, 64, 64, 255}
+		img := solidRows(32, slices.Repeat([]color.RGBA{gray}, 32)...)
+		p := dither(img, color.Palette{color.RGBA{0, 0, 0, 255}, color.RGBA{255, 255, 255, 255}})
+		white := 0
+		for _, i := range p.Pix {
+			white += int(i)
+		}
+		if frac := float64(white) / float64(len(p.Pix)); frac < 0.23 || frac > 0.27 {
+			t.Errorf("%.3f of the pixels are white, want about 0.25", frac)
+		}
+	})
+}
+
+func TestGIFDelays(t *testing.T) {
+	var g gifEncoder
+	img := solidRows(2, color.RGBA{1, 2, 3, 255})
+	// 33ms frames: the 3ms remainders carry over into later frames
+	for i := 0; i < 10; i++ {
+		if err := g.add(img, 33*time.Millisecond); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := g.add(img, time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	want := []int{3, 3, 3, 4, 3, 3, 4, 3, 3, 4, 2}
+	if !slices.Equal(g.anim.Delay, want) {
+		t.Errorf("delays %v, want %v", g.anim.Delay, want)
+	}
+}
@@ -0,0 +1,315 @@
+package capture
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/hubastard/grove/engine/core"
+)
+
+// -------- Clip recorder --------
+
+type Format int
+
+const (
+	FormatGIF         Format = iota // animated GIF, 256-color palette per frame
+	FormatAPNG                      // animated PNG, lossless
+	FormatPNGSequence               // numbered PNG files in a directory
+)
+
+func (f Format) String() string {
+	switch f {
+	case FormatGIF:
+		return "gif"
+	case FormatAPNG:
+		return "apng"
+	case FormatPNGSequence:
+		return "png"
+	}
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+type Options struct {
+	Format    Format
+	Dir       string  // output directory (default: "captures")
+	EveryN    int     // keep one frame out of N (default: 2)
+	Scale     float32 // output size relative to the framebuffer, (0,1] (default: 1)
+	MaxFrames int     // stops recording after this many frames (default: 600)
+}
+
+// Recorder grabs frames from the backbuffer and encodes them into a clip on
+// a worker goroutine. Frames are read back asynchronously, so the render
+// thread never waits for the GPU, and dropped rather than stalling when the
+// worker falls behind.
+type Recorder struct {
+	opts Options
+	sess *session // nil when idle
+	wg   sync.WaitGroup
+}
+
+type frame struct {
+	img *image.RGBA
+	at  time.Time
+}
+
+type session struct {
+	path     string
+	frames   chan frame
+	stopped  bool // frames is closed; readbacks still in flight are dropped
+	tick     int
+	inflight int // frames being read back
+	kept     int
+	dropped  int
+}
+
+func NewRecorder(opts Options) *Recorder {
+	if opts.Dir == "" {
+		opts.Dir = "captures"
+	}
+	if opts.EveryN <= 0 {
+		opts.EveryN = 2
+	}
+	if opts.Scale <= 0 || opts.Scale > 1 {
+		opts.Scale = 1
+	}
+	if opts.MaxFrames <= 0 {
+		opts.MaxFrames = 600
+	}
+	return &Recorder{opts: opts}
+}
+
+func (r *Recorder) Recording() bool { return r.sess != nil }
+
+// Frames returns the number of frames captured by the current recording.
+func (r *Recorder) Frames() int {
+	if r.sess == nil {
+		return 0
+	}
+	return r.sess.kept
+}
+
+// Start begins a new clip. A previous clip may still be encoding.
+func (r *Recorder) Start() error {
+	if r.sess != nil {
+		return fmt.Errorf("capture: already recording")
+	}
+	if err := os.MkdirAll(r.opts.Dir, 0o755); err != nil {
+		return fmt.Errorf("capture: %w", err)
+	}
+
+	name := "clip_" + time.Now().Format("2006-01-02_15-04-05")
+	if r.opts.Format != FormatPNGSequence {
+		name += "." + r.opts.Format.String()
+	}
+	s := &session{path: filepath.Join(r.opts.Dir, name), frames: make(chan frame, 8)}
+
+	var enc encoder
+	switch r.opts.Format {
+	case FormatGIF:
+		enc = &gifEncoder{}
+	case FormatAPNG:
+		enc = &apngEncoder{}
+	case FormatPNGSequence:
+		if err := os.MkdirAll(s.path, 0o755); err != nil {
+			return fmt.Errorf("capture: %w", err)
+		}
+		enc = &pngSeqEncoder{dir: s.path}
+	default:
+		return fmt.Errorf("capture: unknown format %v", r.opts.Format)
+	}
+
+	r.sess = s
+	r.wg.Add(1)
+	go r.encode(s, enc)
+	log.Printf("capture: recording %s", s.path)
+	return nil
+}
+
+// Stop ends the clip; the file is finished in the background.
+func (r *Recorder) Stop() {
+	s := r.sess
+	if s == nil {
+		return
+	}
+	if s.dropped > 0 {
+		log.Printf("capture: dropped %d frames (encoder too slow)", s.dropped)
+	}
+	s.stopped = true
+	close(s.frames)
+	r.sess = nil
+}
+
+// Toggle starts or stops recording.
+func (r *Recorder) Toggle() error {
+	if r.Recording() {
+		r.Stop()
+		return nil
+	}
+	return r.Start()
+}
+
+// Wait blocks until all stopped clips are written.
+func (r *Recorder) Wait() { r.wg.Wait() }
+
+// Capture starts reading back the current backbuffer when recording. Call it
+// once per frame on the render thread, after the content to record has been
+// drawn. The frame reaches the encoder a frame or two later; frames still in
+// flight when the clip stops are dropped.
+func (r *Recorder) Capture(e *core.Engine) {
+	s := r.sess
+	if s == nil {
+		return
+	}
+	s.tick++
+	if (s.tick-1)%r.opts.EveryN != 0 || s.kept+s.inflight >= r.opts.MaxFrames {
+		return
+	}
+
+	at := time.Now()
+	err := e.CaptureFrameAsync(func(img *image.RGBA, err error) {
+		s.inflight--
+		if s.stopped {
+			return
+		}
+		if err != nil {
+			log.Printf("capture: %v", err)
+			r.Stop()
+			return
+		}
+		select {
+		case s.frames <- frame{img: img, at: at}:
+			s.kept++
+		default:
+			s.dropped++
+		}
+		if s.kept >= r.opts.MaxFrames {
+			r.Stop()
+		}
+	})
+	if err != nil {
+		log.Printf("capture: %v", err)
+		r.Stop()
+		return
+	}
+	s.inflight++
+}
+
+type encoder interface {
+	add(img *image.RGBA, delay time.Duration) error
+	finish(path string) error
+}
+
+func (r *Recorder) encode(s *session, enc encoder) {
+	defer r.wg.Done()
+
+	// Frames after a window resize are fitted to the size of the first. If a
+	// frame fails, the clip ends with the frames before it.
+	var (
+		prev    frame
+		n       int
+		failed  error
+		first   image.Point // framebuffer size of the first frame
+		out     image.Point // encoded size
+		fitted  int         // frames fitted to the first size
+		process = func(f frame, delay time.Duration) {
+			if failed != nil {
+				return
+			}
+			img := f.img
+			if first == (image.Point{}) {
+				first = img.Rect.Size()
+			}
+			switch {
+			case img.Rect.Size() != first:
+				img = fit(img, out)
+				fitted++
+			case r.opts.Scale < 1:
+				img = rescale(img, r.opts.Scale)
+			}
+			out = img.Rect.Size()
+			opaque(img)
+			if failed = enc.add(img, delay); failed == nil {
+				n++
+			}
+		}
+	)
+
+	// Each frame is held until the next arrives, which gives its duration.
+	for f := range s.frames {
+		if prev.img != nil {
+			process(prev, f.at.Sub(prev.at))
+		}
+		prev = f
+	}
+	if prev.img != nil {
+		process(prev, time.Second/60*time.Duration(r.opts.EveryN))
+	}
+
+	if failed != nil {
+		log.Printf("capture: %s: frame %d: %v", s.path, n+1, failed)
+	}
+	if fitted > 0 {
+		log.Printf("capture: %d frames fitted to the first frame's %dx%d", fitted, out.X, out.Y)
+	}
+	if n == 0 {
+		log.Printf("capture: no frames recorded")
+		return
+	}
+	if err := enc.finish(s.path); err != nil {
+		log.Printf("capture: %s: %v", s.path, err)
+		return
+	}
+	log.Printf("capture: wrote %s (%d frames)", s.path, n)
+}
+
+// opaque forces alpha to 255; blending leaves partial alpha in the backbuffer.
+func opaque(img *image.RGBA) {
+	for i := 3; i < len(img.Pix); i += 4 {
+		img.Pix[i] = 0xff
+	}
+}
+
+// fit scales src to fit size, keeping its aspect, centered on black.
+func fit(src *image.RGBA, size image.Point) *image.RGBA {
+	scale := min(float32(size.X)/float32(src.Rect.Dx()), float32(size.Y)/float32(src.Rect.Dy()))
+	scaled := rescale(src, scale)
+	dst := image.NewRGBA(image.Rectangle{Max: size})
+	at := size.Sub(scaled.Rect.Size()).Div(2)
+	draw.Draw(dst, scaled.Rect.Add(at), scaled, image.Point{}, draw.Src)
+	return dst
+}
+
+// rescale box-filters src to scale times its size (nearest when enlarging).
+func rescale(src *image.RGBA, scale float32) *image.RGBA {
+	sw, sh := src.Rect.Dx(), src.Rect.Dy()
+	dw, dh := max(1, int(float32(sw)*scale)), max(1, int(float32(sh)*scale))
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+
+	for y := 0; y < dh; y++ {
+		y0, y1 := y*sh/dh, max((y+1)*sh/dh, y*sh/dh+1)
+		for x := 0; x < dw; x++ {
+			x0, x1 := x*sw/dw, max((x+1)*sw/dw, x*sw/dw+1)
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					sum[0] += int(p[0])
+					sum[1] += int(p[1])
+					sum[2] += int(p[2])
+					sum[3] += int(p[3])
+				}
+			}
+			n := (y1 - y0) * (x1 - x0)
+			d := dst.Pix[y*dst.Stride+x*4:]
+			d[0], d[1], d[2], d[3] = uint8(sum[0]/n), uint8(sum[1]/n), uint8(sum[2]/n), uint8(sum[3]/n)
+		}
+	}
+	return dst
+}
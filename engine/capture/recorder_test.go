@@ -0,0 +1,119 @@
+package capture
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/hubastard/grove/engine/core"
+)
+
+// asyncReader is a Renderer whose readbacks complete when deliver is called,
+// like a later BeginFrame.
+type asyncReader struct {
+	core.Renderer
+	pending []func([]byte, error)
+	fail    error
+}
+
+func (r *asyncReader) ReadPixelsAsync(x, y, w, h int, done func([]byte, error)) error {
+	r.pending = append(r.pending, done)
+	return nil
+}
+
+func (r *asyncReader) deliver() {
+	pending := r.pending
+	r.pending = nil
+	for _, done := range pending {
+		if r.fail != nil {
+			done(nil, r.fail)
+			continue
+		}
+		done(make([]byte, 4*3*4), nil)
+	}
+}
+
+type fixedWindow struct{ core.Window }
+
+func (fixedWindow) FramebufferSize() (int, int) { return 4, 3 }
+
+func newTestRecorder(t *testing.T, maxFrames int) (*Recorder, *asyncReader, *core.Engine) {
+	t.Helper()
+	r := &asyncReader{}
+	e := &core.Engine{Window: fixedWindow{}, Renderer: r}
+	rec := NewRecorder(Options{Format: FormatPNGSequence, Dir: t.TempDir(), EveryN: 1, MaxFrames: maxFrames})
+	if err := rec.Start(); err != nil {
+		t.Fatal(err)
+	}
+	return rec, r, e
+}
+
+func TestRecorderAsyncFrames(t *testing.T) {
+	rec, r, e := newTestRecorder(t, 3)
+	path := rec.sess.path
+
+	rec.Capture(e)
+	rec.Capture(e)
+	if rec.Frames() != 0 {
+		t.Errorf("%d frames before any readback finished", rec.Frames())
+	}
+	r.deliver()
+	if rec.Frames() != 2 {
+		t.Errorf("%d frames after two readbacks, want 2", rec.Frames())
+	}
+
+	// no more readbacks than MaxFrames
+	rec.Capture(e)
+	rec.Capture(e)
+	if len(r.pending) != 1 {
+		t.Errorf("%d readbacks in flight, want 1", len(r.pending))
+	}
+	r.deliver()
+	if rec.Recording() {
+		t.Error("still recording after MaxFrames")
+	}
+	rec.Wait()
+
+	files, err := os.ReadDir(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 3 {
+		t.Errorf("wrote %d frames, want 3", len(files))
+	}
+}
+
+func TestRecorderStopWithFramesInFlight(t *testing.T) {
+	rec, r, e := newTestRecorder(t, 10)
+	rec.Capture(e)
+	rec.Capture(e)
+	rec.Stop()
+	r.deliver() // after the clip's channel is closed
+	rec.Wait()
+	if rec.Recording() {
+		t.Error("recording after Stop")
+	}
+
+	// a new clip is not fed the old clip's frames
+	if err := rec.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer rec.Wait()
+	defer rec.Stop()
+	rec.Capture(e)
+	r.deliver()
+	if rec.Frames() != 1 {
+		t.Errorf("%d frames in the new clip, want 1", rec.Frames())
+	}
+}
+
+func TestRecorderReadbackError(t *testing.T) {
+	rec, r, e := newTestRecorder(t, 10)
+	rec.Capture(e)
+	r.fail = errors.New("lost")
+	r.deliver()
+	if rec.Recording() {
+		t.Error("still recording after a failed readback")
+	}
+	rec.Wait()
+}
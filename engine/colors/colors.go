@@ -1,5 +1,7 @@
 package colors
 
+import "math"
+
 type Color [4]float32
 
 var (
@@ -19,3 +21,37 @@ func (c Color) WithAlpha(a float32) Color {
 	c[3] = a
 	return c
 }
+
+// -------- sRGB <-> linear --------
+
+// Color values are authored in sRGB (as picked in an editor). A linear
+// pipeline (core.Config.LinearColor) shades and blends in linear space, so
+// colors handed straight to the GPU (clear color, uniforms) are converted
+// with Linear. Renderer2D keeps vertex colors in sRGB and converts them in its
+// vertex shader.
+
+// SRGBToLinear decodes one sRGB channel in [0,1].
+func SRGBToLinear(v float32) float32 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return float32(math.Pow((float64(v)+0.055)/1.055, 2.4))
+}
+
+// LinearToSRGB encodes one linear channel in [0,1].
+func LinearToSRGB(v float32) float32 {
+	if v <= 0.0031308 {
+		return v * 12.92
+	}
+	return float32(1.055*math.Pow(float64(v), 1/2.4) - 0.055)
+}
+
+// Linear converts an sRGB color to linear; alpha is unchanged.
+func (c Color) Linear() Color {
+	return Color{SRGBToLinear(c[0]), SRGBToLinear(c[1]), SRGBToLinear(c[2]), c[3]}
+}
+
+// SRGB converts a linear color to sRGB; alpha is unchanged.
+func (c Color) SRGB() Color {
+	return Color{LinearToSRGB(c[0]), LinearToSRGB(c[1]), LinearToSRGB(c[2]), c[3]}
+}
@@ -20,7 +20,11 @@ type Engine struct {
 	Renderer Renderer
 	Input    *Input
 	Layers   LayerStack
+	Queue    *RenderQueue   // sorted draws, submitted after App.OnRender and after each layer's OnRender
+	Frame    FrameConstants // contents of the FrameBlock uniform block this frame
+	frameUB  UniformBuffer
 	start    time.Time
+	shots    screenshots
 }
 
 func (e *Engine) Uptime() time.Duration { return time.Since(e.start) }
@@ -42,13 +46,37 @@ type AttribType int
 
 const (
 	AttribFloat32 AttribType = iota
+	AttribFloat16
+	AttribInt8
+	AttribUint8
+	AttribInt16
+	AttribUint16
+	AttribInt32
+	AttribUint32
 )
 
+// ByteSize returns the size in bytes of a single component of type t.
+func (t AttribType) ByteSize() int {
+	switch t {
+	case AttribInt8, AttribUint8:
+		return 1
+	case AttribFloat16, AttribInt16, AttribUint16:
+		return 2
+	default:
+		return 4
+	}
+}
+
+// IsInteger reports whether t is an integer component type.
+func (t AttribType) IsInteger() bool { return t != AttribFloat32 && t != AttribFloat16 }
+
 type VertexAttrib struct {
-	Location int // layout(location = X)
-	Size     int // number of components (e.g., 2 for vec2, 3 for vec3)
-	Type     AttribType
-	Offset   int // byte offset
+	Location   int // layout(location = X)
+	Size       int // number of components (e.g., 2 for vec2, 3 for vec3)
+	Type       AttribType
+	Normalized bool // integer types: read as floats in [0,1] / [-1,1]; otherwise as ivec/uvec
+	Offset     int  // byte offset
+	Divisor    int  // instances per advance; 0 => per-vertex (instance layouts default to 1)
 }
 
 type VertexLayout struct {
@@ -56,10 +84,36 @@ type VertexLayout struct {
 	Attributes []VertexAttrib
 }
 
+// MeshUsage tells the renderer how often a mesh's data changes.
+type MeshUsage int
+
+const (
+	MeshDynamic MeshUsage = iota // updated now and then; each update orphans the previous storage
+	MeshStatic                   // rarely updated; updates overwrite in place and may wait on the GPU
+	MeshStream                   // rewritten several times per frame; updates go round a fenced ring
+)
+
 type MeshDesc struct {
-	Vertices []float32
-	Indices  []uint32 // optional; empty => draw arrays
-	Layout   VertexLayout
+	Vertices   []float32
+	VertexData []byte   // raw alternative to Vertices for packed layouts
+	Indices    []uint32 // optional; empty => draw arrays
+	Layout     VertexLayout
+	Usage      MeshUsage // sizes in Vertices/VertexData/Indices set the capacity of a stream mesh
+
+	// Optional per-instance data, stored in a second buffer and read with
+	// the attribute divisors of InstanceLayout.
+	Instances      []float32
+	InstanceData   []byte // raw alternative to Instances
+	InstanceLayout VertexLayout
+
+	Label string // debug name shown by GL debug tools
+}
+
+// ShaderSource is GLSL code with the file names of its source-string numbers
+// (see PipelineDesc.VertexFiles).
+type ShaderSource struct {
+	Code  string
+	Files []string
 }
 
 type PipelineDesc struct {
@@ -67,12 +121,24 @@ type PipelineDesc struct {
 	FragmentSource string // GLSL
 	DepthTest      bool
 	Blend          bool
+
+	// Source names used in compile/link errors, indexed by GLSL source-string
+	// number (0 unless the source uses "#line N S" directives).
+	VertexFiles   []string
+	FragmentFiles []string
+
+	// Optional expected vertex layout; when set, creation fails if it doesn't
+	// match the active attributes of the linked program.
+	Layout VertexLayout
+
+	Label string // debug name; defaults to the shader file names
 }
 
 type TextureFormat int
 
 const (
-	TextureRGBA8 TextureFormat = iota
+	TextureRGBA8  TextureFormat = iota
+	TextureSRGBA8               // sRGB-encoded color, decoded to linear when sampled
 )
 
 type TextureDesc struct {
@@ -83,19 +149,73 @@ type TextureDesc struct {
 	MagFilter     string // "nearest" | "linear"
 	WrapU         string // "clamp" | "repeat"
 	WrapV         string // "clamp" | "repeat"
+	Label         string // debug name shown by GL debug tools
+
+	// NonColor marks data textures (normal maps, masks, font coverage) that
+	// Config.LinearColor must not treat as sRGB.
+	NonColor bool
+}
+
+// SamplerDesc is filtering/wrap state that can be shared between textures
+// and overrides the texture's own when bound with it. Same values as
+// TextureDesc.
+type SamplerDesc struct {
+	MinFilter string // "nearest" | "linear"
+	MagFilter string // "nearest" | "linear"
+	WrapU     string // "clamp" | "repeat"
+	WrapV     string // "clamp" | "repeat"
+	Label     string // debug name shown by GL debug tools
+}
+
+// TextureUpload is a texture whose pixels are transferred in the background
+// (see Renderer.CreateTextureAsync).
+type TextureUpload interface {
+	// Texture is the reserved texture; its contents are undefined until Ready.
+	Texture() Texture
+	// Pixels is staging memory for Width*Height*4 bytes. It may be filled from
+	// any goroutine until Submit, and is nil after it.
+	Pixels() []byte
+	// Submit hands the pixels to the renderer; safe from any goroutine.
+	Submit()
+	// Ready reports whether the texture holds the uploaded pixels.
+	Ready() bool
+	// Err is set if the upload failed; the texture is then deleted and never
+	// becomes Ready.
+	Err() error
 }
 
 type Mesh interface{ IsMesh() }
 type Pipeline interface{ IsPipeline() }
 type Texture interface{ IsTexture() }
+type Sampler interface{ IsSampler() }
+type RenderTarget interface{ IsRenderTarget() }
+
+// RenderTargetDesc describes an offscreen framebuffer with an RGBA8 color
+// texture. A multisampled target renders into multisample storage and is
+// copied into its texture by Renderer.ResolveRenderTarget.
+type RenderTargetDesc struct {
+	Width, Height int
+	Samples       int  // MSAA samples, 0 => none (clamped to Caps.MaxSamples)
+	Depth         bool // attach a depth buffer
+	Label         string
+}
+
+// TextureBinding binds a texture to the texture unit given by its index in
+// DrawCmd.Textures.
+type TextureBinding struct {
+	Uniform string // sampler uniform set to the unit, e.g. "uTex" or "uTex[3]"; "" => none
+	Texture Texture
+	Sampler Sampler // nil => the texture's own filtering and wrap
+}
 
-// Uniforms: simple map; for textures use Samplers keyed by uniform name
+// Uniforms: simple map; textures go in Textures, one unit per entry
 type DrawCmd struct {
-	Pipe     Pipeline
-	Mesh     Mesh
-	Count    int                // vertex count if no indices; else ignored
-	Uniforms map[string]any     // e.g. "uMVP": [16]float32
-	Samplers map[string]Texture // e.g. "uTex0": Texture
+	Pipe          Pipeline
+	Mesh          Mesh
+	Count         int              // vertex count if no indices; else ignored
+	InstanceCount int              // > 0 => instanced draw
+	Uniforms      map[string]any   // e.g. "uMVP": [16]float32
+	Textures      []TextureBinding // unit i <- Textures[i]
 }
 
 type Renderer interface {
@@ -104,15 +224,46 @@ type Renderer interface {
 	Clear(r, g, b, a float32)
 	CreateMesh(desc MeshDesc) (Mesh, error)
 	UpdateMesh(mesh Mesh, vertices []float32, indices []uint32) error
+	UpdateMeshData(mesh Mesh, vertices []byte, indices []uint32) error
+	UpdateInstances(mesh Mesh, instances []float32) error
+	UpdateInstanceData(mesh Mesh, instances []byte) error
 	CreatePipeline(desc PipelineDesc) (Pipeline, error)
+	PipelineInfo(p Pipeline) PipelineInfo
 	CreateTexture(desc TextureDesc) (Texture, error)
+	CreateSampler(desc SamplerDesc) (Sampler, error)
+	CreateTextureAsync(desc TextureDesc, onReady func(Texture)) (TextureUpload, error)
+	CreateUniformBuffer(desc UniformBufferDesc) (UniformBuffer, error)
+	UpdateUniformBuffer(ub UniformBuffer, offset int, data []byte) error
+	BindUniformBlock(name string, ub UniformBuffer) // every pipeline declaring block name reads ub
+	CreateRenderTarget(desc RenderTargetDesc) (RenderTarget, error)
+	ResizeRenderTarget(rt RenderTarget, w, h int) error
+	SetRenderTarget(rt RenderTarget)             // nil => window framebuffer; also sets the viewport
+	ResolveRenderTarget(rt RenderTarget)         // multisampled => texture; no-op otherwise
+	RenderTargetTexture(rt RenderTarget) Texture // valid after resolve for multisampled targets
 	Draw(cmd DrawCmd)
+	ReadPixels(x, y, w, h int) ([]byte, error)                      // RGBA8, top-left origin
+	ReadPixelsAsync(x, y, w, h int, done func([]byte, error)) error // like ReadPixels; done runs in a later BeginFrame
+	Caps() Caps
+	Stats() RenderStats // counters of the latest finished frame
+	BeginFrame()
+	EndFrame()
+	PushGPUScope(name string) // GPU timing region; pair with PopGPUScope
+	PopGPUScope()
+	GPUFrameTime() time.Duration
 	Shutdown()
 	GPUVendor() string
 	GPURenderer() string
 	GPUVersion() string
 }
 
+// RenderStats counts renderer work over one frame.
+type RenderStats struct {
+	DrawCalls    int
+	StateChanges int // binds/enables issued
+	StateSkipped int // redundant binds/enables the state cache avoided
+	BufferWaits  int // stream mesh updates that had to wait for the GPU
+}
+
 // -------- Events --------
 
 type Event interface{ isEvent() }
@@ -156,6 +307,11 @@ type Config struct {
 	TickPerSec           int // default: 60
 	VSync                bool
 	ClearColor           colors.Color
-	ScratchAllocCapacity int  // initial scratch allocator capacity in bytes (default: 4 KB)
-	ScratchEnableLogs    bool // if true, log scratch allocator events (default: false)
+	ScratchAllocCapacity int    // initial scratch allocator capacity in bytes (default: 4 KB)
+	ScratchEnableLogs    bool   // if true, log scratch allocator events (default: false)
+	GLDebug              bool   // debug GL context: driver messages, error checks, object labels
+	MSAASamples          int    // multisampling of the window framebuffer, 0 => off
+	LinearColor          bool   // gamma-correct pipeline: sRGB framebuffer, RGBA8 color textures read as sRGB
+	ScreenshotKey        Key    // saves a PNG screenshot (default: F12; -1 disables)
+	ScreenshotDir        string // where screenshots go (default: "screenshots")
 }
@@ -0,0 +1,25 @@
+package core
+
+import "slices"
+
+// Caps reports device limits and features, queried once at renderer init.
+type Caps struct {
+	MaxTextureUnits         int // samplers usable by a fragment shader
+	MaxCombinedTextureUnits int // across all stages
+	MaxTextureSize          int // width/height limit of a 2D texture
+	MaxSamples              int // MSAA sample limit; 0 or 1 => no multisampling
+	MaxVertexAttribs        int
+	MaxUniformBlockSize     int             // bytes
+	MaxUniformBindings      int             // uniform block names bound at once
+	Formats                 []TextureFormat // texture formats CreateTexture accepts
+	Extensions              []string        // e.g. "GL_KHR_debug"
+
+	// LinearColor is set when the renderer was created with
+	// Config.LinearColor: colors are blended in linear space, so sRGB colors
+	// must be converted before they reach a shader.
+	LinearColor bool
+}
+
+func (c Caps) HasExtension(name string) bool { return slices.Contains(c.Extensions, name) }
+
+func (c Caps) SupportsFormat(f TextureFormat) bool { return slices.Contains(c.Formats, f) }
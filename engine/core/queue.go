@@ -0,0 +1,187 @@
+package core
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// -------- Sortable render command queue --------
+
+// SortKey describes where a draw goes in the frame. Pipeline and Texture are
+// caller-assigned ids (only the low 12 bits are used) so draws sharing state
+// end up next to each other.
+type SortKey struct {
+	Layer    uint8
+	Pipeline uint16
+	Texture  uint16
+	Depth    float32
+}
+
+// Pack orders by layer, pipeline, texture, then depth (front to back when
+// smaller depth is closer). Use it for opaque draws.
+//
+//	63      56 55        44 43        32 31            0
+//	| layer   | pipeline   | texture    | depth         |
+func (k SortKey) Pack() uint64 {
+	return uint64(k.Layer)<<56 |
+		uint64(k.Pipeline&0xfff)<<44 |
+		uint64(k.Texture&0xfff)<<32 |
+		uint64(depthBits(k.Depth))
+}
+
+// PackDepthFirst orders by layer, then depth, then pipeline and texture.
+// Use it for translucent draws that must blend in depth order.
+func (k SortKey) PackDepthFirst() uint64 {
+	return uint64(k.Layer)<<56 |
+		uint64(depthBits(k.Depth))<<24 |
+		uint64(k.Pipeline&0xfff)<<12 |
+		uint64(k.Texture&0xfff)
+}
+
+// depthBits maps a float to a uint32 with the same ordering.
+func depthBits(d float32) uint32 {
+	b := math.Float32bits(d)
+	if b&0x80000000 != 0 {
+		return ^b
+	}
+	return b | 0x80000000
+}
+
+// QueuedCmd is a recorded draw with its sort key.
+type QueuedCmd struct {
+	Key uint64
+	Cmd DrawCmd
+	seq uint64 // record order, keeps equal keys stable
+}
+
+// RenderQueue collects DrawCmds from any goroutine and submits them sorted by
+// key on the render thread. Uniform maps and texture slices are retained
+// as-is, so callers must not modify them after Record.
+type RenderQueue struct {
+	mu     sync.Mutex
+	cmds   []QueuedCmd
+	spare  []QueuedCmd // drawn last Submit, reused for the next recording
+	seq    uint64
+	dumpTo io.Writer
+}
+
+func NewRenderQueue(capacity int) *RenderQueue {
+	return &RenderQueue{cmds: make([]QueuedCmd, 0, capacity)}
+}
+
+// Record queues cmd. Safe to call from multiple goroutines.
+func (q *RenderQueue) Record(key uint64, cmd DrawCmd) {
+	q.mu.Lock()
+	q.cmds = append(q.cmds, QueuedCmd{Key: key, Cmd: cmd, seq: q.seq})
+	q.seq++
+	q.mu.Unlock()
+}
+
+// Len returns the number of recorded commands.
+func (q *RenderQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.cmds)
+}
+
+// Commands returns a sorted copy of the recorded commands for inspection.
+func (q *RenderQueue) Commands() []QueuedCmd {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	sortQueued(q.cmds)
+	return slices.Clone(q.cmds)
+}
+
+// DumpNextFrame writes the commands of the next non-empty Submit to w (see
+// Dump).
+func (q *RenderQueue) DumpNextFrame(w io.Writer) {
+	q.mu.Lock()
+	q.dumpTo = w
+	q.mu.Unlock()
+}
+
+// Submit sorts the recorded commands, draws them in one pass and clears the
+// queue. Must be called on the render thread. The lock is only held to take
+// the commands, so other goroutines may record the next batch meanwhile.
+func (q *RenderQueue) Submit(r Renderer) {
+	q.mu.Lock()
+	cmds := q.cmds
+	if len(cmds) == 0 {
+		q.mu.Unlock()
+		return
+	}
+	q.cmds, q.spare = q.spare[:0], nil
+	q.seq = 0
+	dumpTo := q.dumpTo
+	q.dumpTo = nil
+	q.mu.Unlock()
+
+	sortQueued(cmds)
+	if dumpTo != nil {
+		if err := dumpQueued(dumpTo, cmds); err != nil {
+			fmt.Fprintf(dumpTo, "render queue dump: %v\n", err)
+		}
+	}
+	for i := range cmds {
+		r.Draw(cmds[i].Cmd)
+	}
+	clear(cmds) // drop references to maps/resources
+
+	q.mu.Lock()
+	q.spare = cmds[:0]
+	q.mu.Unlock()
+}
+
+// Dump writes the recorded commands, sorted, one per line.
+func (q *RenderQueue) Dump(w io.Writer) error {
+	return dumpQueued(w, q.Commands())
+}
+
+func sortQueued(cmds []QueuedCmd) {
+	slices.SortFunc(cmds, func(a, b QueuedCmd) int {
+		if c := cmp.Compare(a.Key, b.Key); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.seq, b.seq)
+	})
+}
+
+func dumpQueued(w io.Writer, cmds []QueuedCmd) error {
+	if _, err := fmt.Fprintf(w, "render queue: %d commands\n", len(cmds)); err != nil {
+		return err
+	}
+	for i, qc := range cmds {
+		c := qc.Cmd
+		_, err := fmt.Fprintf(w, "%4d key=%016x pipe=%p mesh=%p count=%d instances=%d uniforms=[%s] textures=[%s]\n",
+			i, qc.Key, c.Pipe, c.Mesh, c.Count, c.InstanceCount, sortedKeys(c.Uniforms), textureList(c.Textures))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func textureList(ts []TextureBinding) string {
+	parts := make([]string, len(ts))
+	for i, t := range ts {
+		parts[i] = fmt.Sprintf("%d:%s=%p", i, t.Uniform, t.Texture)
+		if t.Sampler != nil {
+			parts[i] += fmt.Sprintf("/%p", t.Sampler)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func sortedKeys[V any](m map[string]V) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return strings.Join(keys, " ")
+}
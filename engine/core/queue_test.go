@@ -0,0 +1,122 @@
+package core
+
+import (
+	"math"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestSortKeyPack(t *testing.T) {
+	tests := []struct {
+		name        string
+		lo, hi      SortKey // lo must sort strictly before hi
+		depthFirst  bool
+		sameAsFirst bool // lo and hi pack equal instead
+	}{
+		{"layer first", SortKey{Layer: 0, Pipeline: 0xfff, Texture: 0xfff, Depth: 1e9}, SortKey{Layer: 1}, false, false},
+		{"pipeline before texture", SortKey{Pipeline: 1, Texture: 0xfff}, SortKey{Pipeline: 2}, false, false},
+		{"texture before depth", SortKey{Texture: 1, Depth: 1e9}, SortKey{Texture: 2, Depth: -1e9}, false, false},
+		{"depth", SortKey{Depth: 0.25}, SortKey{Depth: 0.5}, false, false},
+		{"negative depth", SortKey{Depth: -2}, SortKey{Depth: -1}, false, false},
+		{"negative before positive", SortKey{Depth: -0.001}, SortKey{Depth: 0.001}, false, false},
+		{"negative infinity", SortKey{Depth: float32(math.Inf(-1))}, SortKey{Depth: -math.MaxFloat32}, false, false},
+		{"pipeline overflow stays in its field", SortKey{Layer: 1, Pipeline: 0xffff}, SortKey{Layer: 2}, false, false},
+		{"texture overflow stays in its field", SortKey{Pipeline: 1, Texture: 0xffff}, SortKey{Pipeline: 2}, false, false},
+		{"only the low 12 bits of ids", SortKey{Pipeline: 0x1005, Texture: 0x2007}, SortKey{Pipeline: 0x005, Texture: 0x007}, false, true},
+
+		{"depth first: layer", SortKey{Layer: 0, Depth: 1e9}, SortKey{Layer: 1, Depth: -1e9}, true, false},
+		{"depth first: depth before pipeline", SortKey{Depth: 1, Pipeline: 0xfff}, SortKey{Depth: 2}, true, false},
+		{"depth first: negative depth", SortKey{Depth: -3, Pipeline: 5}, SortKey{Depth: -2.5}, true, false},
+		{"depth first: pipeline before texture", SortKey{Depth: 1, Pipeline: 1, Texture: 0xfff}, SortKey{Depth: 1, Pipeline: 2}, true, false},
+		{"depth first: texture overflow stays in its field", SortKey{Pipeline: 1, Texture: 0xffff}, SortKey{Pipeline: 2}, true, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			pack := SortKey.Pack
+			if tc.depthFirst {
+				pack = SortKey.PackDepthFirst
+			}
+			lo, hi := pack(tc.lo), pack(tc.hi)
+			if tc.sameAsFirst {
+				if lo != hi {
+					t.Errorf("%016x != %016x", lo, hi)
+				}
+				return
+			}
+			if lo >= hi {
+				t.Errorf("%+v packs to %016x, not before %+v at %016x", tc.lo, lo, tc.hi, hi)
+			}
+		})
+	}
+}
+
+func TestSortKeyLayout(t *testing.T) {
+	k := SortKey{Layer: 0xab, Pipeline: 0x123, Texture: 0x456, Depth: 0}
+	if got, want := k.Pack(), uint64(0xab_123_456_80000000); got != want {
+		t.Errorf("Pack = %016x, want %016x", got, want)
+	}
+	if got, want := k.PackDepthFirst(), uint64(0xab_80000000_123_456); got != want {
+		t.Errorf("PackDepthFirst = %016x, want %016x", got, want)
+	}
+}
+
+// drawRecorder is a Renderer that only records Draw calls.
+type drawRecorder struct {
+	Renderer
+	draws []int
+}
+
+func (r *drawRecorder) Draw(cmd DrawCmd) { r.draws = append(r.draws, cmd.Count) }
+
+func TestRenderQueueSubmit(t *testing.T) {
+	q := NewRenderQueue(4)
+	// Count identifies each command; equal keys must keep record order.
+	for i, key := range []uint64{3, 1, 2, 1, 3, 1, 0} {
+		q.Record(key, DrawCmd{Count: i})
+	}
+	r := &drawRecorder{}
+	q.Submit(r)
+	if want := []int{6, 1, 3, 5, 2, 0, 4}; !slices.Equal(r.draws, want) {
+		t.Errorf("drew %v, want %v", r.draws, want)
+	}
+	if q.Len() != 0 {
+		t.Errorf("%d commands left after Submit", q.Len())
+	}
+
+	// the next frame starts over, reusing the buffers
+	r.draws = nil
+	q.Record(5, DrawCmd{Count: 1})
+	q.Record(5, DrawCmd{Count: 2})
+	q.Submit(r)
+	q.Submit(r) // empty
+	if want := []int{1, 2}; !slices.Equal(r.draws, want) {
+		t.Errorf("second frame drew %v, want %v", r.draws, want)
+	}
+}
+
+func TestRenderQueueConcurrentRecord(t *testing.T) {
+	q := NewRenderQueue(0)
+	const workers, each = 8, 200
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < each; i++ {
+				q.Record(uint64(i%7), DrawCmd{Count: i})
+			}
+		}()
+	}
+	wg.Wait()
+	r := &drawRecorder{}
+	q.Submit(r)
+	if len(r.draws) != workers*each {
+		t.Fatalf("drew %d commands, want %d", len(r.draws), workers*each)
+	}
+	for i := 1; i < len(r.draws); i++ {
+		if r.draws[i-1]%7 > r.draws[i]%7 {
+			t.Fatalf("draw %d (key %d) after key %d", i, r.draws[i]%7, r.draws[i-1]%7)
+		}
+	}
+}
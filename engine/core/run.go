@@ -29,9 +29,17 @@ func Run(app App, cfg Config, newWindow func(Config) (Window, error), newRendere
 	if err != nil {
 		return err
 	}
+
+	eng := &Engine{Window: win, Renderer: rend, Input: NewInput(), Queue: NewRenderQueue(1024), start: time.Now()}
+	eng.shots.init(cfg)
+	defer eng.shots.wait() // after Shutdown has delivered pending readbacks
 	defer rend.Shutdown()
 
-	eng := &Engine{Window: win, Renderer: rend, Input: NewInput(), start: time.Now()}
+	eng.frameUB, err = rend.CreateUniformBuffer(UniformBufferDesc{Size: len(eng.Frame.Std140()), Label: "Engine.frame"})
+	if err != nil {
+		return err
+	}
+	rend.BindUniformBlock(FrameBlock, eng.frameUB)
 
 	// authoritative initial size
 	w, h := win.FramebufferSize()
@@ -39,6 +47,9 @@ func Run(app App, cfg Config, newWindow func(Config) (Window, error), newRendere
 
 	win.SetEventCallback(func(ev Event) {
 		eng.Input.Handle(ev)
+		if k, ok := ev.(EventKey); ok && k.Down && k.Key == eng.shots.key {
+			eng.RequestScreenshot()
+		}
 		eng.Layers.ForEachReverse(func(l Layer) bool { return l.OnEvent(eng, ev) })
 		app.OnEvent(eng, ev)
 
@@ -62,11 +73,15 @@ func Run(app App, cfg Config, newWindow func(Config) (Window, error), newRendere
 	}
 
 	tick := time.Second / tps
+	if cfg.LinearColor {
+		cfg.ClearColor = cfg.ClearColor.Linear()
+	}
 	var (
 		accum   time.Duration
 		prev    = time.Now()
 		clear   = cfg.ClearColor
 		maxStep = 10 // prevent spiral of death
+		frames  uint32
 	)
 
 	for !win.ShouldClose() {
@@ -102,9 +117,29 @@ func Run(app App, cfg Config, newWindow func(Config) (Window, error), newRendere
 
 		// Render
 		scopeRender := profiler.Start("Render")
+		rend.BeginFrame()
+		fw, fh := win.FramebufferSize()
+		eng.Frame = FrameConstants{
+			Resolution: [2]float32{float32(fw), float32(fh)},
+			Time:       float32(now.Sub(eng.start).Seconds()),
+			DeltaTime:  float32(frame.Seconds()),
+			FrameIndex: frames,
+		}
+		frames++
+		if err := rend.UpdateUniformBuffer(eng.frameUB, 0, eng.Frame.Std140()); err != nil {
+			log.Printf("frame constants: %v", err)
+		}
+		rend.PushGPUScope("Layers")
 		rend.Clear(clear[0], clear[1], clear[2], clear[3])
 		app.OnRender(eng, alpha)
-		eng.Layers.ForEach(func(l Layer) { l.OnRender(eng, alpha) })
+		eng.submitQueue()
+		eng.Layers.ForEach(func(l Layer) {
+			l.OnRender(eng, alpha)
+			eng.submitQueue() // under the layers above
+		})
+		rend.PopGPUScope()
+		eng.shots.capture(eng)
+		rend.EndFrame()
 		scopeRender.End()
 
 		// Frame end (we don't include SwapBuffers in profiling)
@@ -122,3 +157,15 @@ func Run(app App, cfg Config, newWindow func(Config) (Window, error), newRendere
 	log.Println("Engine exit")
 	return nil
 }
+
+// submitQueue draws what was recorded into Queue so far.
+func (e *Engine) submitQueue() {
+	if e.Queue.Len() == 0 {
+		return
+	}
+	scope := profiler.Start("RenderQueue.Submit")
+	e.Renderer.PushGPUScope("RenderQueue.Submit")
+	e.Queue.Submit(e.Renderer)
+	e.Renderer.PopGPUScope()
+	scope.End()
+}
@@ -0,0 +1,111 @@
+package core
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// -------- Screenshots --------
+
+type screenshots struct {
+	key     Key
+	dir     string
+	pending bool
+	wg      sync.WaitGroup // PNG encoders still writing
+}
+
+func (s *screenshots) init(cfg Config) {
+	s.key = cfg.ScreenshotKey
+	if s.key == 0 {
+		s.key = KeyF12
+	}
+	s.dir = cfg.ScreenshotDir
+	if s.dir == "" {
+		s.dir = "screenshots"
+	}
+}
+
+// capture starts saving the frame if a screenshot was requested. It runs
+// after all drawing, before the swap. The pixels arrive a frame or two later
+// without stalling for the GPU, and are encoded off the render thread.
+func (s *screenshots) capture(e *Engine) {
+	if !s.pending {
+		return
+	}
+	s.pending = false
+
+	path := filepath.Join(s.dir, time.Now().Format("2006-01-02_15-04-05.000")+".png")
+	err := e.CaptureFrameAsync(func(img *image.RGBA, err error) {
+		if err != nil {
+			log.Printf("screenshot: %v", err)
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			if err := SavePNG(path, img); err != nil {
+				log.Printf("screenshot: %v", err)
+				return
+			}
+			log.Printf("screenshot: %s", path)
+		}()
+	})
+	if err != nil {
+		log.Printf("screenshot: %v", err)
+	}
+}
+
+// wait blocks until pending screenshots are written (on exit).
+func (s *screenshots) wait() { s.wg.Wait() }
+
+// RequestScreenshot saves the current frame as a timestamped PNG in
+// Config.ScreenshotDir once it has been rendered. Encoding happens off the
+// render thread.
+func (e *Engine) RequestScreenshot() { e.shots.pending = true }
+
+// CaptureFrame reads back the whole framebuffer. Call it from OnRender (or
+// later in the frame) to get what has been drawn so far.
+func (e *Engine) CaptureFrame() (*image.RGBA, error) {
+	w, h := e.Window.FramebufferSize()
+	pix, err := e.Renderer.ReadPixels(0, 0, w, h)
+	if err != nil {
+		return nil, err
+	}
+	return &image.RGBA{Pix: pix, Stride: w * 4, Rect: image.Rect(0, 0, w, h)}, nil
+}
+
+// CaptureFrameAsync reads back the whole framebuffer like CaptureFrame
+// without waiting for the GPU: done gets the image on the render thread at
+// the start of a later frame.
+func (e *Engine) CaptureFrameAsync(done func(*image.RGBA, error)) error {
+	w, h := e.Window.FramebufferSize()
+	return e.Renderer.ReadPixelsAsync(0, 0, w, h, func(pix []byte, err error) {
+		if err != nil {
+			done(nil, err)
+			return
+		}
+		done(&image.RGBA{Pix: pix, Stride: w * 4, Rect: image.Rect(0, 0, w, h)}, nil)
+	})
+}
+
+// SavePNG writes img to path, creating the parent directory.
+func SavePNG(path string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return fmt.Errorf("encode %s: %w", path, err)
+	}
+	return f.Close()
+}
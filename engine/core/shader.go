@@ -0,0 +1,190 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// -------- Shader reflection (filled by the backend at pipeline creation) --------
+
+// ShaderType is the GLSL type of an active attribute or uniform.
+type ShaderType int
+
+const (
+	ShaderUnknown ShaderType = iota
+	ShaderFloat
+	ShaderVec2
+	ShaderVec3
+	ShaderVec4
+	ShaderInt
+	ShaderIVec2
+	ShaderIVec3
+	ShaderIVec4
+	ShaderUint
+	ShaderUVec2
+	ShaderUVec3
+	ShaderUVec4
+	ShaderBool
+	ShaderMat2
+	ShaderMat3
+	ShaderMat4
+	ShaderSampler2D
+)
+
+var shaderTypeNames = [...]string{
+	ShaderUnknown:   "unknown",
+	ShaderFloat:     "float",
+	ShaderVec2:      "vec2",
+	ShaderVec3:      "vec3",
+	ShaderVec4:      "vec4",
+	ShaderInt:       "int",
+	ShaderIVec2:     "ivec2",
+	ShaderIVec3:     "ivec3",
+	ShaderIVec4:     "ivec4",
+	ShaderUint:      "uint",
+	ShaderUVec2:     "uvec2",
+	ShaderUVec3:     "uvec3",
+	ShaderUVec4:     "uvec4",
+	ShaderBool:      "bool",
+	ShaderMat2:      "mat2",
+	ShaderMat3:      "mat3",
+	ShaderMat4:      "mat4",
+	ShaderSampler2D: "sampler2D",
+}
+
+func (t ShaderType) String() string {
+	if t < 0 || int(t) >= len(shaderTypeNames) {
+		return shaderTypeNames[ShaderUnknown]
+	}
+	return shaderTypeNames[t]
+}
+
+// Components returns the number of components per column (4 for vec4 and mat4).
+func (t ShaderType) Components() int {
+	switch t {
+	case ShaderVec2, ShaderIVec2, ShaderUVec2, ShaderMat2:
+		return 2
+	case ShaderVec3, ShaderIVec3, ShaderUVec3, ShaderMat3:
+		return 3
+	case ShaderVec4, ShaderIVec4, ShaderUVec4, ShaderMat4:
+		return 4
+	default:
+		return 1
+	}
+}
+
+// Columns returns the number of attribute locations a value of this type uses.
+func (t ShaderType) Columns() int {
+	switch t {
+	case ShaderMat2:
+		return 2
+	case ShaderMat3:
+		return 3
+	case ShaderMat4:
+		return 4
+	default:
+		return 1
+	}
+}
+
+// IsInteger reports whether the shader reads t as signed/unsigned integers.
+func (t ShaderType) IsInteger() bool {
+	switch t {
+	case ShaderInt, ShaderIVec2, ShaderIVec3, ShaderIVec4,
+		ShaderUint, ShaderUVec2, ShaderUVec3, ShaderUVec4:
+		return true
+	}
+	return false
+}
+
+func (t ShaderType) IsSampler() bool { return t == ShaderSampler2D }
+
+// ShaderVar is an active attribute or uniform of a linked pipeline.
+type ShaderVar struct {
+	Name      string // array uniforms are reported without the "[0]" suffix
+	Type      ShaderType
+	Location  int // -1 for uniforms living in a uniform block
+	ArraySize int // 1 for non-arrays
+}
+
+// PipelineInfo lists what the linked shaders actually consume.
+type PipelineInfo struct {
+	Attributes []ShaderVar
+	Uniforms   []ShaderVar
+	Blocks     []UniformBlock
+}
+
+// Block returns the active uniform block called name.
+func (pi PipelineInfo) Block(name string) (UniformBlock, bool) {
+	for _, b := range pi.Blocks {
+		if b.Name == name {
+			return b, true
+		}
+	}
+	return UniformBlock{}, false
+}
+
+// Attribute returns the active attribute called name.
+func (pi PipelineInfo) Attribute(name string) (ShaderVar, bool) {
+	for _, a := range pi.Attributes {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return ShaderVar{}, false
+}
+
+// Uniform returns the active uniform called name. Array elements ("uTex[3]")
+// resolve to their array.
+func (pi PipelineInfo) Uniform(name string) (ShaderVar, bool) {
+	if i := strings.IndexByte(name, '['); i >= 0 {
+		name = name[:i]
+	}
+	for _, u := range pi.Uniforms {
+		if u.Name == name {
+			return u, true
+		}
+	}
+	return ShaderVar{}, false
+}
+
+// ValidateLayout checks that the vertex layouts feed every active attribute
+// at its location with the right kind (float/integer) and no more components
+// than it reads; missing components are filled from (0, 0, 0, 1) like GL
+// does, so a vec2 buffer may feed a vec4 input. Layout attributes the shader
+// doesn't read are ignored.
+func (pi PipelineInfo) ValidateLayout(layouts ...VertexLayout) error {
+	fed := map[int]VertexAttrib{}
+	for _, l := range layouts {
+		for _, a := range l.Attributes {
+			fed[a.Location] = a
+		}
+	}
+
+	var errs []error
+	for _, in := range pi.Attributes {
+		if strings.HasPrefix(in.Name, "gl_") {
+			continue
+		}
+		for col := 0; col < in.Type.Columns(); col++ {
+			loc := in.Location + col
+			a, ok := fed[loc]
+			if !ok {
+				errs = append(errs, fmt.Errorf("attribute %s %s (location %d) is not fed by the vertex layout", in.Type, in.Name, loc))
+				continue
+			}
+			if a.Size > in.Type.Components() {
+				errs = append(errs, fmt.Errorf("attribute %s %s (location %d) reads %d components, layout provides %d", in.Type, in.Name, loc, in.Type.Components(), a.Size))
+			}
+			if fedInt := a.Type.IsInteger() && !a.Normalized; fedInt != in.Type.IsInteger() {
+				kind := "floats"
+				if fedInt {
+					kind = "integers"
+				}
+				errs = append(errs, fmt.Errorf("attribute %s %s (location %d) is fed %s", in.Type, in.Name, loc, kind))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,78 @@
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateLayout(t *testing.T) {
+	info := PipelineInfo{Attributes: []ShaderVar{
+		{Name: "aPos", Type: ShaderVec4, Location: 0},
+		{Name: "aIndex", Type: ShaderUint, Location: 1},
+		{Name: "aModel", Type: ShaderMat3, Location: 2}, // locations 2-4
+		{Name: "gl_VertexID", Type: ShaderInt, Location: -1},
+	}}
+	full := []VertexAttrib{
+		{Location: 0, Size: 4, Type: AttribFloat32},
+		{Location: 1, Size: 1, Type: AttribUint32},
+		{Location: 2, Size: 3, Type: AttribFloat32},
+		{Location: 3, Size: 3, Type: AttribFloat32},
+		{Location: 4, Size: 3, Type: AttribFloat32},
+	}
+	with := func(a VertexAttrib) []VertexAttrib {
+		l := append([]VertexAttrib(nil), full...)
+		for i := range l {
+			if l[i].Location == a.Location {
+				l[i] = a
+			}
+		}
+		return l
+	}
+
+	tests := []struct {
+		name  string
+		attrs []VertexAttrib
+		want  string // substring of the error, "" => valid
+	}{
+		{"exact", full, ""},
+		{"fewer components", with(VertexAttrib{Location: 0, Size: 2, Type: AttribFloat32}), ""},
+		{"fewer matrix components", with(VertexAttrib{Location: 3, Size: 2, Type: AttribFloat32}), ""},
+		{"normalized integers feed floats", with(VertexAttrib{Location: 0, Size: 4, Type: AttribUint8, Normalized: true}), ""},
+		{"half floats feed floats", with(VertexAttrib{Location: 0, Size: 4, Type: AttribFloat16}), ""},
+		{"extra unread attribute", append(with(VertexAttrib{Location: 0, Size: 4, Type: AttribFloat32}), VertexAttrib{Location: 7, Size: 2, Type: AttribFloat32}), ""},
+		{"more components", with(VertexAttrib{Location: 1, Size: 2, Type: AttribUint32}), "aIndex (location 1) reads 1 components, layout provides 2"},
+		{"more matrix components", with(VertexAttrib{Location: 4, Size: 4, Type: AttribFloat32}), "aModel (location 4) reads 3 components, layout provides 4"},
+		{"integers feed floats", with(VertexAttrib{Location: 0, Size: 4, Type: AttribInt16}), "aPos (location 0) is fed integers"},
+		{"floats feed integers", with(VertexAttrib{Location: 1, Size: 1, Type: AttribFloat32}), "aIndex (location 1) is fed floats"},
+		{"normalized feed integers", with(VertexAttrib{Location: 1, Size: 1, Type: AttribUint8, Normalized: true}), "aIndex (location 1) is fed floats"},
+		{"missing matrix column", full[:4], "aModel (location 4) is not fed"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := info.ValidateLayout(VertexLayout{Attributes: tc.attrs})
+			switch {
+			case tc.want == "" && err != nil:
+				t.Errorf("unexpected error: %v", err)
+			case tc.want != "" && err == nil:
+				t.Errorf("no error, want %q", tc.want)
+			case tc.want != "" && !strings.Contains(err.Error(), tc.want):
+				t.Errorf("error %q, want %q", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestValidateLayoutInstanced(t *testing.T) {
+	info := PipelineInfo{Attributes: []ShaderVar{
+		{Name: "aPos", Type: ShaderVec2, Location: 0},
+		{Name: "aOffset", Type: ShaderVec2, Location: 1},
+	}}
+	vert := VertexLayout{Attributes: []VertexAttrib{{Location: 0, Size: 2, Type: AttribFloat32}}}
+	inst := VertexLayout{Attributes: []VertexAttrib{{Location: 1, Size: 2, Type: AttribFloat32}}}
+	if err := info.ValidateLayout(vert, inst); err != nil {
+		t.Errorf("per-instance layout: %v", err)
+	}
+	if err := info.ValidateLayout(vert); err == nil {
+		t.Error("no error without the per-instance layout")
+	}
+}
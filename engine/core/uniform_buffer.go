@@ -0,0 +1,114 @@
+package core
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// -------- Uniform buffers --------
+
+type UniformBuffer interface{ IsUniformBuffer() }
+
+type UniformBufferDesc struct {
+	Size  int    // bytes
+	Data  []byte // optional initial contents (len <= Size)
+	Label string // debug name shown by GL debug tools
+}
+
+// UniformBlock is an active uniform block of a linked pipeline.
+type UniformBlock struct {
+	Name string
+	Size int // minimum buffer size in bytes
+}
+
+// FrameBlock is the uniform block the engine fills once per frame. Shaders
+// opt in by declaring it:
+//
+//	layout(std140) uniform Frame {
+//	    vec2  uResolution; // framebuffer size in pixels
+//	    float uTime;       // seconds since start
+//	    float uDeltaTime;  // seconds since the previous frame
+//	    uint  uFrameIndex;
+//	};
+const FrameBlock = "Frame"
+
+// FrameConstants mirrors FrameBlock.
+type FrameConstants struct {
+	Resolution [2]float32
+	Time       float32
+	DeltaTime  float32
+	FrameIndex uint32
+}
+
+// Std140 returns c packed for FrameBlock.
+func (c FrameConstants) Std140() []byte {
+	var w Std140
+	w.Vec2(c.Resolution)
+	w.Float(c.Time)
+	w.Float(c.DeltaTime)
+	w.Uint(c.FrameIndex)
+	return w.End()
+}
+
+// Std140 packs values with the std140 layout rules, in declaration order.
+// Arrays and structs are not handled; use vec4s for those.
+type Std140 struct {
+	buf []byte
+}
+
+func (w *Std140) align(n int) {
+	for len(w.buf)%n != 0 {
+		w.buf = append(w.buf, 0)
+	}
+}
+
+func (w *Std140) Float(v float32) {
+	w.align(4)
+	w.buf = binary.LittleEndian.AppendUint32(w.buf, math.Float32bits(v))
+}
+
+func (w *Std140) Int(v int32) {
+	w.align(4)
+	w.buf = binary.LittleEndian.AppendUint32(w.buf, uint32(v))
+}
+
+func (w *Std140) Uint(v uint32) {
+	w.align(4)
+	w.buf = binary.LittleEndian.AppendUint32(w.buf, v)
+}
+
+func (w *Std140) Vec2(v [2]float32) {
+	w.align(8)
+	w.floats(v[:])
+}
+
+func (w *Std140) Vec3(v [3]float32) {
+	w.align(16)
+	w.floats(v[:])
+}
+
+func (w *Std140) Vec4(v [4]float32) {
+	w.align(16)
+	w.floats(v[:])
+}
+
+// Mat4 writes a column-major mat4.
+func (w *Std140) Mat4(m [16]float32) {
+	w.align(16)
+	w.floats(m[:])
+}
+
+func (w *Std140) floats(v []float32) {
+	for _, f := range v {
+		w.buf = binary.LittleEndian.AppendUint32(w.buf, math.Float32bits(f))
+	}
+}
+
+// End pads the block to a multiple of 16 bytes and returns it.
+func (w *Std140) End() []byte {
+	w.align(16)
+	return w.buf
+}
+
+// Reset clears the writer, keeping its buffer.
+func (w *Std140) Reset() { w.buf = w.buf[:0] }
@@ -0,0 +1,130 @@
+package glbackend
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"unsafe"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/hubastard/grove/engine/core"
+)
+
+// Debug mode (core.Config.GLDebug): KHR_debug messages are routed to the log
+// by severity, glGetError is checked after every renderer entry point and GL
+// objects get readable labels in tools like RenderDoc.
+
+// initDebug installs the debug message callback when the context supports
+// KHR_debug (core in GL 4.3). It reports whether labels can be attached.
+func (r *RendererGL) initDebug() bool {
+	if !hasDebugOutput(r.caps) {
+		log.Println("gl: debug mode requested but KHR_debug is not supported; only glGetError checks are active")
+		return false
+	}
+
+	var flags int32
+	gl.GetIntegerv(gl.CONTEXT_FLAGS, &flags)
+	if flags&gl.CONTEXT_FLAG_DEBUG_BIT == 0 {
+		log.Println("gl: context was created without the debug flag; driver messages may be limited")
+	}
+
+	gl.Enable(gl.DEBUG_OUTPUT)
+	gl.Enable(gl.DEBUG_OUTPUT_SYNCHRONOUS) // report on the offending call's stack
+	gl.DebugMessageCallback(onDebugMessage, nil)
+	// Notifications (buffer placement hints etc.) are too chatty to keep.
+	gl.DebugMessageControl(gl.DONT_CARE, gl.DONT_CARE, gl.DEBUG_SEVERITY_NOTIFICATION, 0, nil, false)
+	return true
+}
+
+func hasDebugOutput(caps core.Caps) bool {
+	var major, minor int32
+	gl.GetIntegerv(gl.MAJOR_VERSION, &major)
+	gl.GetIntegerv(gl.MINOR_VERSION, &minor)
+	if major > 4 || (major == 4 && minor >= 3) {
+		return true
+	}
+	return caps.HasExtension("GL_KHR_debug")
+}
+
+func onDebugMessage(source, gltype, id, severity uint32, _ int32, message string, _ unsafe.Pointer) {
+	level := "info"
+	switch severity {
+	case gl.DEBUG_SEVERITY_HIGH:
+		level = "error"
+	case gl.DEBUG_SEVERITY_MEDIUM:
+		level = "warn"
+	case gl.DEBUG_SEVERITY_NOTIFICATION:
+		return
+	}
+	log.Printf("gl: [%s] %s/%s #%d: %s", level, debugSourceName(source), debugTypeName(gltype), id, strings.TrimSpace(message))
+}
+
+// checkError drains glGetError after a renderer entry point in debug mode.
+func (r *RendererGL) checkError(where string) {
+	if !r.debug {
+		return
+	}
+	for i := 0; i < 8; i++ { // bounded: a lost context can report forever
+		code := gl.GetError()
+		if code == gl.NO_ERROR {
+			return
+		}
+		log.Printf("gl: %s: %s", where, errorName(code))
+	}
+}
+
+// label attaches a debug label to a GL object (gl.BUFFER, gl.TEXTURE, ...).
+func (r *RendererGL) label(identifier, name uint32, text string) {
+	if !r.labels || name == 0 || text == "" {
+		return
+	}
+	gl.ObjectLabel(identifier, name, int32(len(text)), gl.Str(text+"\x00"))
+}
+
+func errorName(code uint32) string {
+	switch code {
+	case gl.INVALID_ENUM:
+		return "GL_INVALID_ENUM"
+	case gl.INVALID_VALUE:
+		return "GL_INVALID_VALUE"
+	case gl.INVALID_OPERATION:
+		return "GL_INVALID_OPERATION"
+	case gl.INVALID_FRAMEBUFFER_OPERATION:
+		return "GL_INVALID_FRAMEBUFFER_OPERATION"
+	case gl.OUT_OF_MEMORY:
+		return "GL_OUT_OF_MEMORY"
+	}
+	return fmt.Sprintf("GL error 0x%04X", code)
+}
+
+func debugSourceName(s uint32) string {
+	switch s {
+	case gl.DEBUG_SOURCE_API:
+		return "api"
+	case gl.DEBUG_SOURCE_WINDOW_SYSTEM:
+		return "window"
+	case gl.DEBUG_SOURCE_SHADER_COMPILER:
+		return "shader"
+	case gl.DEBUG_SOURCE_THIRD_PARTY:
+		return "third-party"
+	case gl.DEBUG_SOURCE_APPLICATION:
+		return "app"
+	}
+	return "other"
+}
+
+func debugTypeName(t uint32) string {
+	switch t {
+	case gl.DEBUG_TYPE_ERROR:
+		return "error"
+	case gl.DEBUG_TYPE_DEPRECATED_BEHAVIOR:
+		return "deprecated"
+	case gl.DEBUG_TYPE_UNDEFINED_BEHAVIOR:
+		return "undefined"
+	case gl.DEBUG_TYPE_PORTABILITY:
+		return "portability"
+	case gl.DEBUG_TYPE_PERFORMANCE:
+		return "performance"
+	}
+	return "other"
+}
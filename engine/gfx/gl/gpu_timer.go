@@ -0,0 +1,180 @@
+package glbackend
+
+import (
+	"time"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/hubastard/grove/engine/core"
+	"github.com/hubastard/grove/engine/profiler"
+)
+
+// GPU timing uses GL_TIMESTAMP queries written around scopes. Results are
+// read back gpuFrameLatency frames later, only once available, so the CPU
+// never waits on the GPU. A frame whose results are still pending when its
+// slot comes around again is dropped.
+
+const gpuFrameLatency = 4
+
+// Recalibrate the GPU->CPU clock offset this often (clocks drift slowly).
+const gpuCalibrateEvery = 120
+
+type gpuScopeRec struct {
+	name       string
+	begin, end int // query indices
+}
+
+type gpuFrame struct {
+	queries []uint32 // pooled query objects; grows as needed
+	used    int
+	scopes  []gpuScopeRec
+	stack   []int // open scope indices
+	pending bool  // recorded, waiting for results
+}
+
+type gpuTimers struct {
+	enabled   bool
+	frames    [gpuFrameLatency]gpuFrame
+	cur       int
+	recording bool
+
+	offsetNS  int64 // cpuNS - gpuNS
+	sinceCal  int
+	frameTime time.Duration // last resolved frame
+	resolved  []profiler.GPUScope
+}
+
+// hasTimerQuery reports ARB_timer_query support (core since GL 3.3).
+func hasTimerQuery(caps core.Caps) bool {
+	var major, minor int32
+	gl.GetIntegerv(gl.MAJOR_VERSION, &major)
+	gl.GetIntegerv(gl.MINOR_VERSION, &minor)
+	if major > 3 || (major == 3 && minor >= 3) {
+		return true
+	}
+	return caps.HasExtension("GL_ARB_timer_query")
+}
+
+func (t *gpuTimers) init(caps core.Caps) {
+	t.enabled = hasTimerQuery(caps)
+	if t.enabled {
+		t.calibrate()
+	}
+}
+
+func (t *gpuTimers) calibrate() {
+	var gpuNS int64
+	gl.GetInteger64v(gl.TIMESTAMP, &gpuNS)
+	t.offsetNS = time.Now().UnixNano() - gpuNS
+	t.sinceCal = 0
+}
+
+// beginFrame resolves finished frames and starts recording the next one.
+func (t *gpuTimers) beginFrame() {
+	if !t.enabled {
+		return
+	}
+	if t.sinceCal++; t.sinceCal >= gpuCalibrateEvery {
+		t.calibrate()
+	}
+
+	// Oldest first: the slot after cur was recorded gpuFrameLatency-1 frames ago.
+	for i := 1; i <= gpuFrameLatency; i++ {
+		f := &t.frames[(t.cur+i)%gpuFrameLatency]
+		if f.pending {
+			t.resolve(f)
+		}
+	}
+
+	t.cur = (t.cur + 1) % gpuFrameLatency
+	f := &t.frames[t.cur]
+	f.pending = false // dropped if still unresolved
+	f.used = 0
+	f.scopes = f.scopes[:0]
+	f.stack = f.stack[:0]
+	t.recording = true
+	t.push("GPU Frame")
+}
+
+func (t *gpuTimers) endFrame() {
+	if !t.enabled || !t.recording {
+		return
+	}
+	f := &t.frames[t.cur]
+	for len(f.stack) > 0 { // close anything left open, frame scope last
+		t.pop()
+	}
+	f.pending = len(f.scopes) > 0
+	t.recording = false
+}
+
+func (t *gpuTimers) push(name string) {
+	if !t.enabled || !t.recording {
+		return
+	}
+	f := &t.frames[t.cur]
+	f.scopes = append(f.scopes, gpuScopeRec{name: name, begin: f.timestamp(), end: -1})
+	f.stack = append(f.stack, len(f.scopes)-1)
+}
+
+func (t *gpuTimers) pop() {
+	if !t.enabled || !t.recording {
+		return
+	}
+	f := &t.frames[t.cur]
+	if len(f.stack) == 0 {
+		return
+	}
+	i := f.stack[len(f.stack)-1]
+	f.stack = f.stack[:len(f.stack)-1]
+	f.scopes[i].end = f.timestamp()
+}
+
+// timestamp writes a GL_TIMESTAMP query and returns its index.
+func (f *gpuFrame) timestamp() int {
+	if f.used == len(f.queries) {
+		grow := max(16, len(f.queries))
+		ids := make([]uint32, grow)
+		gl.GenQueries(int32(grow), &ids[0])
+		f.queries = append(f.queries, ids...)
+	}
+	gl.QueryCounter(f.queries[f.used], gl.TIMESTAMP)
+	f.used++
+	return f.used - 1
+}
+
+// resolve reads a frame's results if the last query is available.
+func (t *gpuTimers) resolve(f *gpuFrame) {
+	var avail int32
+	gl.GetQueryObjectiv(f.queries[f.used-1], gl.QUERY_RESULT_AVAILABLE, &avail)
+	if avail == gl.FALSE {
+		return // try again next frame
+	}
+	f.pending = false
+
+	t.resolved = t.resolved[:0]
+	for _, sc := range f.scopes {
+		var begin, end uint64
+		gl.GetQueryObjectui64v(f.queries[sc.begin], gl.QUERY_RESULT, &begin)
+		gl.GetQueryObjectui64v(f.queries[sc.end], gl.QUERY_RESULT, &end)
+		t.resolved = append(t.resolved, profiler.GPUScope{
+			Name:    sc.name,
+			StartNS: int64(begin) + t.offsetNS,
+			EndNS:   int64(end) + t.offsetNS,
+		})
+	}
+	if len(t.resolved) > 0 {
+		// scope 0 is the whole frame
+		t.frameTime = time.Duration(t.resolved[0].EndNS - t.resolved[0].StartNS)
+	}
+	profiler.RecordGPU(t.resolved)
+}
+
+func (t *gpuTimers) delete() {
+	for i := range t.frames {
+		f := &t.frames[i]
+		if len(f.queries) > 0 {
+			gl.DeleteQueries(int32(len(f.queries)), &f.queries[0])
+		}
+		*f = gpuFrame{}
+	}
+}
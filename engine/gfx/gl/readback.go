@@ -0,0 +1,110 @@
+package glbackend
+
+import (
+	"fmt"
+	"time"
+	"unsafe"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// Async readbacks copy framebuffer pixels into a pixel pack buffer. The copy
+// is queued with the frame, so glReadPixels returns at once; a fence tells a
+// later BeginFrame when the buffer can be mapped without waiting.
+
+type readback struct {
+	pbo   uint32
+	w, h  int
+	fence uintptr
+	done  func([]byte, error)
+}
+
+// ReadPixelsAsync starts reading a w*h RGBA8 region of the bound framebuffer,
+// like ReadPixels. done gets the pixels on the render thread in a later
+// BeginFrame (usually the next one or two), or in Shutdown.
+func (r *RendererGL) ReadPixelsAsync(x, y, w, h int, done func([]byte, error)) error {
+	defer r.checkError("ReadPixelsAsync")
+	if w <= 0 || h <= 0 || x < 0 || y < 0 || x+w > r.fbW || y+h > r.fbH {
+		return fmt.Errorf("ReadPixelsAsync: region %dx%d at (%d,%d) outside %dx%d framebuffer", w, h, x, y, r.fbW, r.fbH)
+	}
+
+	rb := &readback{w: w, h: h, done: done}
+	gl.GenBuffers(1, &rb.pbo)
+	gl.BindBuffer(gl.PIXEL_PACK_BUFFER, rb.pbo)
+	gl.BufferData(gl.PIXEL_PACK_BUFFER, w*h*4, nil, gl.STREAM_READ)
+	gl.PixelStorei(gl.PACK_ALIGNMENT, 1)
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, r.readFBO())                                          // resolved pixels of a multisampled target
+	gl.ReadPixels(int32(x), int32(r.fbH-y-h), int32(w), int32(h), gl.RGBA, gl.UNSIGNED_BYTE, nil) // into the PBO
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, r.boundFBO())
+	gl.BindBuffer(gl.PIXEL_PACK_BUFFER, 0)
+	rb.fence = gl.FenceSync(gl.SYNC_GPU_COMMANDS_COMPLETE, 0)
+	r.readbacks = append(r.readbacks, rb)
+	return nil
+}
+
+// pollReadbacks completes the readbacks whose copy has finished; with wait
+// set it blocks until all have.
+func (r *RendererGL) pollReadbacks(wait bool) {
+	var (
+		flags   uint32
+		timeout uint64
+	)
+	if wait {
+		flags, timeout = gl.SYNC_FLUSH_COMMANDS_BIT, uint64(time.Second)
+	}
+	var done []*readback
+	pending := r.readbacks[:0]
+	for _, rb := range r.readbacks {
+		if gl.ClientWaitSync(rb.fence, flags, timeout) == gl.TIMEOUT_EXPIRED {
+			pending = append(pending, rb)
+			continue
+		}
+		done = append(done, rb)
+	}
+	clear(r.readbacks[len(pending):])
+	r.readbacks = pending
+
+	for _, rb := range done {
+		pix, err := rb.read()
+		rb.done(pix, err)
+	}
+}
+
+// dropReadbacks frees the readbacks still pending, whose done gets an error.
+func (r *RendererGL) dropReadbacks() {
+	for _, rb := range r.readbacks {
+		rb.free()
+		rb.done(nil, fmt.Errorf("ReadPixelsAsync: copy of %dx%d pixels not finished at shutdown", rb.w, rb.h))
+	}
+	clear(r.readbacks)
+	r.readbacks = r.readbacks[:0]
+}
+
+func (rb *readback) free() {
+	gl.DeleteSync(rb.fence)
+	gl.DeleteBuffers(1, &rb.pbo)
+}
+
+// read copies the pixels out of the PBO, top row first, and frees it.
+func (rb *readback) read() ([]byte, error) {
+	defer rb.free()
+	size := rb.w * rb.h * 4
+	gl.BindBuffer(gl.PIXEL_PACK_BUFFER, rb.pbo)
+	defer gl.BindBuffer(gl.PIXEL_PACK_BUFFER, 0)
+	ptr := gl.MapBufferRange(gl.PIXEL_PACK_BUFFER, 0, size, gl.MAP_READ_BIT)
+	if ptr == nil {
+		return nil, fmt.Errorf("ReadPixelsAsync: could not map %d byte readback buffer", size)
+	}
+	src := unsafe.Slice((*byte)(ptr), size)
+
+	// GL rows start at the bottom
+	stride := rb.w * 4
+	pix := make([]byte, size)
+	for row := 0; row < rb.h; row++ {
+		copy(pix[row*stride:(row+1)*stride], src[(rb.h-1-row)*stride:])
+	}
+	if !gl.UnmapBuffer(gl.PIXEL_PACK_BUFFER) {
+		return nil, fmt.Errorf("ReadPixelsAsync: readback buffer lost while mapped")
+	}
+	return pix, nil
+}
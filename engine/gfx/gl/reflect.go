@@ -0,0 +1,241 @@
+package glbackend
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/hubastard/grove/engine/colors"
+	"github.com/hubastard/grove/engine/core"
+)
+
+// uniformGL is a resolved uniform (or single array element) of a program.
+type uniformGL struct {
+	loc   int32
+	typ   core.ShaderType
+	count int // elements from loc onwards (array length for the array name)
+}
+
+// reflectProgram queries the active attributes, uniforms and uniform blocks
+// of a linked program. The returned map resolves uniform names, array names and every
+// array element ("uTex[3]") to its location.
+func reflectProgram(prog uint32) (core.PipelineInfo, map[string]uniformGL) {
+	var info core.PipelineInfo
+	uniforms := map[string]uniformGL{}
+
+	var attrLen, uniLen int32
+	gl.GetProgramiv(prog, gl.ACTIVE_ATTRIBUTE_MAX_LENGTH, &attrLen)
+	gl.GetProgramiv(prog, gl.ACTIVE_UNIFORM_MAX_LENGTH, &uniLen)
+	buf := make([]uint8, max(attrLen, uniLen)+1)
+
+	var n int32
+	gl.GetProgramiv(prog, gl.ACTIVE_ATTRIBUTES, &n)
+	for i := uint32(0); i < uint32(n); i++ {
+		var length, size int32
+		var xtype uint32
+		gl.GetActiveAttrib(prog, i, int32(len(buf)), &length, &size, &xtype, &buf[0])
+		name := string(buf[:length])
+		info.Attributes = append(info.Attributes, core.ShaderVar{
+			Name:      name,
+			Type:      toShaderType(xtype),
+			Location:  int(gl.GetAttribLocation(prog, gl.Str(name+"\x00"))),
+			ArraySize: int(size),
+		})
+	}
+
+	gl.GetProgramiv(prog, gl.ACTIVE_UNIFORMS, &n)
+	for i := uint32(0); i < uint32(n); i++ {
+		var length, size int32
+		var xtype uint32
+		gl.GetActiveUniform(prog, i, int32(len(buf)), &length, &size, &xtype, &buf[0])
+		name := strings.TrimSuffix(string(buf[:length]), "[0]")
+		typ := toShaderType(xtype)
+		loc := gl.GetUniformLocation(prog, gl.Str(name+"\x00"))
+
+		info.Uniforms = append(info.Uniforms, core.ShaderVar{
+			Name:      name,
+			Type:      typ,
+			Location:  int(loc),
+			ArraySize: int(size),
+		})
+		if loc < 0 {
+			continue // lives in a uniform block
+		}
+		uniforms[name] = uniformGL{loc: loc, typ: typ, count: int(size)}
+		if size > 1 {
+			for e := 0; e < int(size); e++ {
+				elem := name + "[" + strconv.Itoa(e) + "]"
+				if l := gl.GetUniformLocation(prog, gl.Str(elem+"\x00")); l >= 0 {
+					uniforms[elem] = uniformGL{loc: l, typ: typ, count: int(size) - e}
+				}
+			}
+		}
+	}
+
+	gl.GetProgramiv(prog, gl.ACTIVE_UNIFORM_BLOCKS, &n)
+	for i := uint32(0); i < uint32(n); i++ {
+		var nameLen, size, length int32
+		gl.GetActiveUniformBlockiv(prog, i, gl.UNIFORM_BLOCK_NAME_LENGTH, &nameLen)
+		gl.GetActiveUniformBlockiv(prog, i, gl.UNIFORM_BLOCK_DATA_SIZE, &size)
+		name := make([]uint8, nameLen+1)
+		gl.GetActiveUniformBlockName(prog, i, int32(len(name)), &length, &name[0])
+		info.Blocks = append(info.Blocks, core.UniformBlock{Name: string(name[:length]), Size: int(size)})
+	}
+	return info, uniforms
+}
+
+func toShaderType(t uint32) core.ShaderType {
+	switch t {
+	case gl.FLOAT:
+		return core.ShaderFloat
+	case gl.FLOAT_VEC2:
+		return core.ShaderVec2
+	case gl.FLOAT_VEC3:
+		return core.ShaderVec3
+	case gl.FLOAT_VEC4:
+		return core.ShaderVec4
+	case gl.INT:
+		return core.ShaderInt
+	case gl.INT_VEC2:
+		return core.ShaderIVec2
+	case gl.INT_VEC3:
+		return core.ShaderIVec3
+	case gl.INT_VEC4:
+		return core.ShaderIVec4
+	case gl.UNSIGNED_INT:
+		return core.ShaderUint
+	case gl.UNSIGNED_INT_VEC2:
+		return core.ShaderUVec2
+	case gl.UNSIGNED_INT_VEC3:
+		return core.ShaderUVec3
+	case gl.UNSIGNED_INT_VEC4:
+		return core.ShaderUVec4
+	case gl.BOOL:
+		return core.ShaderBool
+	case gl.FLOAT_MAT2:
+		return core.ShaderMat2
+	case gl.FLOAT_MAT3:
+		return core.ShaderMat3
+	case gl.FLOAT_MAT4:
+		return core.ShaderMat4
+	case gl.SAMPLER_2D:
+		return core.ShaderSampler2D
+	}
+	return core.ShaderUnknown
+}
+
+// setUniform uploads v to u. It returns false when the Go value doesn't
+// match the GLSL type, in which case nothing is sent.
+func setUniform(u uniformGL, v any) bool {
+	switch x := v.(type) {
+	case float32:
+		if u.typ != core.ShaderFloat {
+			return false
+		}
+		gl.Uniform1f(u.loc, x)
+	case float64:
+		if u.typ != core.ShaderFloat {
+			return false
+		}
+		gl.Uniform1f(u.loc, float32(x))
+	case int:
+		return setUniformInt(u, int64(x))
+	case int32:
+		return setUniformInt(u, int64(x))
+	case uint32:
+		return setUniformInt(u, int64(x))
+	case bool:
+		if u.typ != core.ShaderBool {
+			return false
+		}
+		b := int32(0)
+		if x {
+			b = 1
+		}
+		gl.Uniform1i(u.loc, b)
+	case [2]float32:
+		if u.typ != core.ShaderVec2 {
+			return false
+		}
+		gl.Uniform2f(u.loc, x[0], x[1])
+	case [3]float32:
+		if u.typ != core.ShaderVec3 {
+			return false
+		}
+		gl.Uniform3f(u.loc, x[0], x[1], x[2])
+	case [4]float32:
+		return setUniformVec4(u, x)
+	case colors.Color:
+		return setUniformVec4(u, x)
+	case [9]float32:
+		if u.typ != core.ShaderMat3 {
+			return false
+		}
+		gl.UniformMatrix3fv(u.loc, 1, false, &x[0])
+	case [16]float32:
+		if u.typ != core.ShaderMat4 {
+			return false
+		}
+		gl.UniformMatrix4fv(u.loc, 1, false, &x[0])
+	case []float32:
+		return setUniformFloats(u, x)
+	case []int32:
+		if len(x) == 0 || (u.typ != core.ShaderInt && !u.typ.IsSampler()) {
+			return false
+		}
+		gl.Uniform1iv(u.loc, int32(min(len(x), u.count)), &x[0])
+	default:
+		return false
+	}
+	return true
+}
+
+func setUniformInt(u uniformGL, v int64) bool {
+	switch {
+	case u.typ == core.ShaderInt || u.typ == core.ShaderBool || u.typ.IsSampler():
+		gl.Uniform1i(u.loc, int32(v))
+	case u.typ == core.ShaderUint:
+		gl.Uniform1ui(u.loc, uint32(v))
+	default:
+		return false
+	}
+	return true
+}
+
+func setUniformVec4(u uniformGL, v [4]float32) bool {
+	switch u.typ {
+	case core.ShaderVec4:
+		gl.Uniform4f(u.loc, v[0], v[1], v[2], v[3])
+	case core.ShaderMat2:
+		gl.UniformMatrix2fv(u.loc, 1, false, &v[0])
+	default:
+		return false
+	}
+	return true
+}
+
+// setUniformFloats uploads a flat float slice to a float/vector/matrix array.
+func setUniformFloats(u uniformGL, v []float32) bool {
+	per := u.typ.Components() * u.typ.Columns()
+	if len(v) == 0 || len(v)%per != 0 {
+		return false
+	}
+	n := int32(min(len(v)/per, u.count))
+	switch u.typ {
+	case core.ShaderFloat:
+		gl.Uniform1fv(u.loc, n, &v[0])
+	case core.ShaderVec2:
+		gl.Uniform2fv(u.loc, n, &v[0])
+	case core.ShaderVec3:
+		gl.Uniform3fv(u.loc, n, &v[0])
+	case core.ShaderVec4:
+		gl.Uniform4fv(u.loc, n, &v[0])
+	case core.ShaderMat3:
+		gl.UniformMatrix3fv(u.loc, n, false, &v[0])
+	case core.ShaderMat4:
+		gl.UniformMatrix4fv(u.loc, n, false, &v[0])
+	default:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,202 @@
+package glbackend
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/hubastard/grove/engine/core"
+)
+
+// A render target is a framebuffer object with a color texture. When
+// multisampled, drawing goes to multisample renderbuffers on fbo and
+// ResolveRenderTarget blits them into the texture, which is attached to a
+// second framebuffer (resolve). Otherwise the texture is attached to fbo and
+// resolve == fbo.
+
+type rtGL struct {
+	fbo, resolve uint32
+	color, depth uint32 // renderbuffers (color only when multisampled)
+	tex          *texGL
+	w, h         int
+	samples      int
+	hasDepth     bool
+	label        string
+}
+
+func (rtGL) IsRenderTarget() {}
+
+func (r *RendererGL) CreateRenderTarget(desc core.RenderTargetDesc) (core.RenderTarget, error) {
+	defer r.checkError("CreateRenderTarget")
+	t := &rtGL{samples: min(max(desc.Samples, 0), r.caps.MaxSamples), hasDepth: desc.Depth, label: desc.Label}
+	if t.samples == 1 {
+		t.samples = 0
+	}
+	if err := r.allocTarget(t, desc.Width, desc.Height); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// ResizeRenderTarget reallocates the attachments; the contents are lost.
+func (r *RendererGL) ResizeRenderTarget(rt core.RenderTarget, w, h int) error {
+	defer r.checkError("ResizeRenderTarget")
+	t := rt.(*rtGL)
+	if t.w == w && t.h == h {
+		return nil
+	}
+	// build the new attachments first, so t stays usable if that fails
+	next := &rtGL{samples: t.samples, hasDepth: t.hasDepth, label: t.label}
+	if err := r.allocTarget(next, w, h); err != nil {
+		return err
+	}
+	r.freeTarget(t)
+	*t = *next
+	if r.target == t {
+		r.SetRenderTarget(t)
+	}
+	return nil
+}
+
+func (r *RendererGL) SetRenderTarget(rt core.RenderTarget) {
+	defer r.checkError("SetRenderTarget")
+	if rt == nil {
+		r.target = nil
+		gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+		r.setViewport(r.winW, r.winH)
+		return
+	}
+	t := rt.(*rtGL)
+	r.target = t
+	gl.BindFramebuffer(gl.FRAMEBUFFER, t.fbo)
+	r.setViewport(t.w, t.h)
+}
+
+func (r *RendererGL) ResolveRenderTarget(rt core.RenderTarget) {
+	defer r.checkError("ResolveRenderTarget")
+	t := rt.(*rtGL)
+	if t.samples == 0 {
+		return
+	}
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, t.fbo)
+	gl.BindFramebuffer(gl.DRAW_FRAMEBUFFER, t.resolve)
+	w, h := int32(t.w), int32(t.h)
+	gl.BlitFramebuffer(0, 0, w, h, 0, 0, w, h, gl.COLOR_BUFFER_BIT, gl.NEAREST)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, r.boundFBO())
+}
+
+func (r *RendererGL) RenderTargetTexture(rt core.RenderTarget) core.Texture {
+	return rt.(*rtGL).tex
+}
+
+func (r *RendererGL) setViewport(w, h int) {
+	gl.Viewport(0, 0, int32(w), int32(h))
+	r.fbW, r.fbH = w, h
+}
+
+func (r *RendererGL) boundFBO() uint32 {
+	if r.target == nil {
+		return 0
+	}
+	return r.target.fbo
+}
+
+// readFBO is the framebuffer holding the final pixels of the bound target.
+func (r *RendererGL) readFBO() uint32 {
+	if r.target == nil {
+		return 0
+	}
+	return r.target.resolve
+}
+
+func (r *RendererGL) checkTargetSize(t *rtGL, w, h int) error {
+	if w <= 0 || h <= 0 || w > r.caps.MaxTextureSize || h > r.caps.MaxTextureSize {
+		return fmt.Errorf("render target %q: invalid size %dx%d (max %d)", t.label, w, h, r.caps.MaxTextureSize)
+	}
+	return nil
+}
+
+// allocTarget creates the attachments of t at w x h. On failure t is left
+// without any.
+func (r *RendererGL) allocTarget(t *rtGL, w, h int) error {
+	if err := r.checkTargetSize(t, w, h); err != nil {
+		return err
+	}
+
+	desc := core.TextureDesc{
+		Width: w, Height: h, Format: core.TextureRGBA8,
+		MinFilter: "linear", MagFilter: "linear", WrapU: "clamp", WrapV: "clamp",
+		Label: t.label + ".color",
+	}
+	internal, err := r.internalFormat(desc) // sRGB under LinearColor, like the window
+	if err != nil {
+		return err
+	}
+	tex, err := r.newTexture(desc, nil)
+	if err != nil {
+		return err
+	}
+	t.tex = tex
+
+	gl.GenFramebuffers(1, &t.fbo)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, t.fbo)
+	if t.samples > 0 {
+		gl.GenRenderbuffers(1, &t.color)
+		gl.BindRenderbuffer(gl.RENDERBUFFER, t.color)
+		gl.RenderbufferStorageMultisample(gl.RENDERBUFFER, int32(t.samples), uint32(internal), int32(w), int32(h))
+		gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.RENDERBUFFER, t.color)
+	} else {
+		gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, tex.id, 0)
+	}
+	if t.hasDepth {
+		gl.GenRenderbuffers(1, &t.depth)
+		gl.BindRenderbuffer(gl.RENDERBUFFER, t.depth)
+		gl.RenderbufferStorageMultisample(gl.RENDERBUFFER, int32(t.samples), gl.DEPTH_COMPONENT24, int32(w), int32(h))
+		gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_ATTACHMENT, gl.RENDERBUFFER, t.depth)
+	}
+	gl.BindRenderbuffer(gl.RENDERBUFFER, 0)
+	err = checkFramebuffer(t.label)
+
+	t.resolve = t.fbo
+	if err == nil && t.samples > 0 {
+		gl.GenFramebuffers(1, &t.resolve)
+		gl.BindFramebuffer(gl.FRAMEBUFFER, t.resolve)
+		gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, tex.id, 0)
+		err = checkFramebuffer(t.label + " (resolve)")
+	}
+	gl.BindFramebuffer(gl.FRAMEBUFFER, r.boundFBO())
+	if err != nil {
+		r.freeTarget(t)
+		return err
+	}
+
+	r.label(gl.FRAMEBUFFER, t.fbo, t.label)
+	if t.resolve != t.fbo {
+		r.label(gl.FRAMEBUFFER, t.resolve, t.label+".resolve")
+	}
+	t.w, t.h = w, h
+	return nil
+}
+
+func (r *RendererGL) freeTarget(t *rtGL) {
+	if t.resolve != t.fbo {
+		gl.DeleteFramebuffers(1, &t.resolve)
+	}
+	gl.DeleteFramebuffers(1, &t.fbo)
+	for _, rb := range []uint32{t.color, t.depth} {
+		if rb != 0 {
+			gl.DeleteRenderbuffers(1, &rb)
+		}
+	}
+	if t.tex != nil {
+		r.deleteTexture(t.tex.id)
+	}
+	t.fbo, t.resolve, t.color, t.depth, t.tex = 0, 0, 0, 0, nil
+	t.w, t.h = 0, 0
+}
+
+func checkFramebuffer(label string) error {
+	if s := gl.CheckFramebufferStatus(gl.FRAMEBUFFER); s != gl.FRAMEBUFFER_COMPLETE {
+		return fmt.Errorf("render target %q: framebuffer incomplete (0x%X)", label, s)
+	}
+	return nil
+}
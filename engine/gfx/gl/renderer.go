@@ -2,11 +2,14 @@ package glbackend
 
 import (
 	"fmt"
+	"log"
 	"strings"
+	"time"
 	"unsafe"
 
 	"github.com/go-gl/gl/v3.3-core/gl"
 	"github.com/hubastard/grove/engine/core"
+	"github.com/hubastard/grove/engine/profiler"
 )
 
 // ---------- GL handles implementing core.Mesh / core.Pipeline / core.Texture ----------
@@ -20,6 +23,17 @@ type meshGL struct {
 	stride    int32
 	vCapBytes int
 	iCapBytes int
+
+	// per-instance buffer (0 if none)
+	instVBO      uint32
+	instCapBytes int
+
+	usage        core.MeshUsage
+	vRing, iRing *streamRing // MeshStream only
+	vBase        int         // first vertex of the latest update in the vertex buffer
+	iOffset      int         // byte offset of the latest indices in the index buffer
+
+	layout, instLayout core.VertexLayout // kept for validation against pipelines
 }
 
 func (meshGL) IsMesh() {}
@@ -28,6 +42,14 @@ type pipeGL struct {
 	prog      uint32
 	depthTest bool
 	blend     bool
+
+	name     string
+	info     core.PipelineInfo
+	uniforms map[string]uniformGL
+	units    map[int32]int32  // sampler uniform location -> unit last set
+	checked  map[*meshGL]bool // meshes whose layout was validated
+	blocksOK bool             // uniform block buffers checked on first draw
+	warned   map[string]bool  // warnings already logged once
 }
 
 func (pipeGL) IsPipeline() {}
@@ -40,13 +62,39 @@ type texGL struct {
 
 func (texGL) IsTexture() {}
 
+type samplerGL struct {
+	id uint32
+}
+
+func (samplerGL) IsSampler() {}
+
 type RendererGL struct {
 	win                       core.Window
 	vendor, renderer, version string
+
+	debug  bool // check glGetError after each call (core.Config.GLDebug)
+	labels bool // KHR_debug available: label GL objects
+	caps   core.Caps
+
+	blockBindings map[string]uint32 // uniform block name -> binding point
+	blockBufs     map[uint32]*uboGL // buffer bound at each binding point
+
+	state     boundState
+	stats     core.RenderStats // frame being recorded
+	lastStats core.RenderStats // latest finished frame
+	gpu       gpuTimers
+	uploads   []*textureUpload // async texture uploads in progress
+	readbacks []*readback      // async pixel reads in progress
+	target    *rtGL            // bound render target, nil => window
+	winW      int              // window framebuffer size
+	winH      int
+	fbW, fbH  int  // size of the bound framebuffer (for ReadPixels)
+	msaa      bool // window framebuffer is multisampled (core.Config.MSAASamples)
+	linear    bool // core.Config.LinearColor
 }
 
-func NewRendererGL(win core.Window, _ core.Config) (*RendererGL, error) {
-	r := &RendererGL{win: win}
+func NewRendererGL(win core.Window, cfg core.Config) (*RendererGL, error) {
+	r := &RendererGL{win: win, debug: cfg.GLDebug, msaa: cfg.MSAASamples > 0, linear: cfg.LinearColor, state: boundState{depth: -1, blend: -1, unit: -1}}
 	if err := r.Init(); err != nil {
 		return nil, err
 	}
@@ -54,60 +102,191 @@ func NewRendererGL(win core.Window, _ core.Config) (*RendererGL, error) {
 }
 
 func (r *RendererGL) Init() error {
-	gl.Enable(gl.DEPTH_TEST) // default on
+	defer r.checkError("Init")
+	r.caps = queryCaps()
+	r.caps.LinearColor = r.linear
+	r.state.textures = make([]uint32, r.caps.MaxCombinedTextureUnits)
+	r.state.samplers = make([]uint32, r.caps.MaxCombinedTextureUnits)
+	if r.debug {
+		r.labels = r.initDebug()
+	}
+	r.setDepthTest(true) // default on
+	if r.msaa {
+		gl.Enable(gl.MULTISAMPLE)
+	}
+	if r.linear {
+		gl.Enable(gl.FRAMEBUFFER_SRGB) // encode linear shader output on write
+	}
 	r.vendor = gl.GoStr(gl.GetString(gl.VENDOR))
 	r.renderer = gl.GoStr(gl.GetString(gl.RENDERER))
 	r.version = gl.GoStr(gl.GetString(gl.VERSION))
+	r.gpu.init(r.caps)
 	return nil
 }
-func (r *RendererGL) Shutdown()       {}
-func (r *RendererGL) Resize(w, h int) { gl.Viewport(0, 0, int32(w), int32(h)) }
+
+func (r *RendererGL) Caps() core.Caps { return r.caps }
+
+// Shutdown completes pending readbacks, dropping those the GPU doesn't finish
+// in time, and frees the GPU timers.
+func (r *RendererGL) Shutdown() {
+	r.pollReadbacks(true)
+	r.dropReadbacks()
+	r.gpu.delete()
+}
+
+// BeginFrame collects finished GPU timings, uploads and readbacks and opens
+// the frame's GPU scope.
+func (r *RendererGL) BeginFrame() {
+	defer r.checkError("BeginFrame")
+	r.gpu.beginFrame()
+	r.lastStats, r.stats = r.stats, core.RenderStats{}
+	r.pollUploads()
+	r.pollReadbacks(false)
+}
+
+// Stats returns the counters of the latest finished frame.
+func (r *RendererGL) Stats() core.RenderStats { return r.lastStats }
+
+func (r *RendererGL) EndFrame() {
+	defer r.checkError("EndFrame")
+	r.gpu.endFrame()
+}
+
+// PushGPUScope times the following GPU work on the profiler's GPU track.
+// Scopes are only recorded in "profile" builds; the frame total always is.
+func (r *RendererGL) PushGPUScope(name string) {
+	if profiler.Enabled {
+		r.gpu.push(name)
+	}
+}
+
+func (r *RendererGL) PopGPUScope() {
+	if profiler.Enabled {
+		r.gpu.pop()
+	}
+}
+
+// GPUFrameTime returns the GPU duration of the latest resolved frame (a few
+// frames behind), or 0 when timer queries are unsupported.
+func (r *RendererGL) GPUFrameTime() time.Duration { return r.gpu.frameTime }
+
+func (r *RendererGL) Resize(w, h int) {
+	defer r.checkError("Resize")
+	r.winW, r.winH = w, h
+	if r.target == nil {
+		r.setViewport(w, h)
+	}
+}
+
+// ReadPixels reads a w*h RGBA8 region of the bound framebuffer. x, y and the
+// returned rows use a top-left origin, like window and texture coordinates.
+// Call it after drawing and before the buffers are swapped.
+func (r *RendererGL) ReadPixels(x, y, w, h int) ([]byte, error) {
+	defer r.checkError("ReadPixels")
+	if w <= 0 || h <= 0 || x < 0 || y < 0 || x+w > r.fbW || y+h > r.fbH {
+		return nil, fmt.Errorf("ReadPixels: region %dx%d at (%d,%d) outside %dx%d framebuffer", w, h, x, y, r.fbW, r.fbH)
+	}
+
+	pix := make([]byte, w*h*4)
+	gl.PixelStorei(gl.PACK_ALIGNMENT, 1)
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, r.readFBO()) // resolved pixels of a multisampled target
+	gl.ReadPixels(int32(x), int32(r.fbH-y-h), int32(w), int32(h), gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(pix))
+	gl.BindFramebuffer(gl.READ_FRAMEBUFFER, r.boundFBO())
+
+	// GL rows start at the bottom
+	stride := w * 4
+	tmp := make([]byte, stride)
+	for top, bot := 0, h-1; top < bot; top, bot = top+1, bot-1 {
+		a, b := pix[top*stride:(top+1)*stride], pix[bot*stride:(bot+1)*stride]
+		copy(tmp, a)
+		copy(a, b)
+		copy(b, tmp)
+	}
+	return pix, nil
+}
 
 func (r *RendererGL) Clear(rf, gf, bf, af float32) {
+	defer r.checkError("Clear")
 	gl.ClearColor(rf, gf, bf, af)
 	gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
 }
 
 func (r *RendererGL) CreateMesh(desc core.MeshDesc) (core.Mesh, error) {
+	defer r.checkError("CreateMesh")
+	for _, layout := range []core.VertexLayout{desc.Layout, desc.InstanceLayout} {
+		for _, a := range layout.Attributes {
+			if _, ok := toGLAttribType(a.Type); !ok {
+				return nil, fmt.Errorf("unsupported attrib type %d at location %d", a.Type, a.Location)
+			}
+		}
+	}
+
+	vertices := desc.VertexData
+	if vertices == nil {
+		vertices = float32Bytes(desc.Vertices)
+	}
+	instances := desc.InstanceData
+	if instances == nil {
+		instances = float32Bytes(desc.Instances)
+	}
+
+	usage := uint32(gl.DYNAMIC_DRAW)
+	if desc.Usage == core.MeshStatic {
+		usage = gl.STATIC_DRAW
+	}
+	stream := desc.Usage == core.MeshStream
+	var vRing, iRing *streamRing
+
 	var vao, vbo, ebo uint32
 	gl.GenVertexArrays(1, &vao)
-	gl.BindVertexArray(vao)
+	r.bindVAO(vao)
 
 	gl.GenBuffers(1, &vbo)
 	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
-	vSize := len(desc.Vertices) * 4
-	var vPtr unsafe.Pointer
-	if len(desc.Vertices) > 0 {
-		vPtr = gl.Ptr(desc.Vertices)
+	vSize := len(vertices)
+	if stream {
+		vRing = newStreamRing(gl.ARRAY_BUFFER, vSize, &r.stats.BufferWaits)
+	} else {
+		gl.BufferData(gl.ARRAY_BUFFER, vSize, bytesPtr(vertices), usage)
 	}
-	gl.BufferData(gl.ARRAY_BUFFER, vSize, vPtr, gl.DYNAMIC_DRAW)
 
 	iSize := len(desc.Indices) * 4
 	if len(desc.Indices) > 0 {
 		gl.GenBuffers(1, &ebo)
 		gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, ebo)
-		var iPtr unsafe.Pointer
-		if len(desc.Indices) > 0 {
-			iPtr = gl.Ptr(desc.Indices)
+		if stream {
+			iRing = newStreamRing(gl.ELEMENT_ARRAY_BUFFER, iSize, &r.stats.BufferWaits)
+		} else {
+			gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, iSize, gl.Ptr(desc.Indices), usage)
 		}
-		gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, iSize, iPtr, gl.DYNAMIC_DRAW)
 	}
 
-	for _, a := range desc.Layout.Attributes {
-		if a.Type != core.AttribFloat32 {
-			return nil, fmt.Errorf("unsupported attrib type")
-		}
-		gl.EnableVertexAttribArray(uint32(a.Location))
-		gl.VertexAttribPointer(uint32(a.Location), int32(a.Size), gl.FLOAT, false, int32(desc.Layout.Stride), unsafe.Pointer(uintptr(a.Offset)))
+	setupAttribs(desc.Layout, false)
+
+	// optional per-instance buffer
+	var instVBO uint32
+	instSize := len(instances)
+	if len(desc.InstanceLayout.Attributes) > 0 {
+		gl.GenBuffers(1, &instVBO)
+		gl.BindBuffer(gl.ARRAY_BUFFER, instVBO)
+		gl.BufferData(gl.ARRAY_BUFFER, instSize, bytesPtr(instances), usage)
+		setupAttribs(desc.InstanceLayout, true)
 	}
 
 	// Keep EBO bound to VAO association
-	gl.BindVertexArray(0)
+	r.bindVAO(0)
 	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
 
+	if desc.Label != "" {
+		r.label(gl.VERTEX_ARRAY, vao, desc.Label)
+		r.label(gl.BUFFER, vbo, desc.Label+".vertices")
+		r.label(gl.BUFFER, ebo, desc.Label+".indices")
+		r.label(gl.BUFFER, instVBO, desc.Label+".instances")
+	}
+
 	nVtx := 0
-	if desc.Layout.Stride > 0 && len(desc.Vertices) > 0 && len(desc.Indices) == 0 {
-		nVtx = len(desc.Vertices) * 4 / desc.Layout.Stride
+	if desc.Layout.Stride > 0 && vSize > 0 && len(desc.Indices) == 0 {
+		nVtx = vSize / desc.Layout.Stride
 	}
 	m := &meshGL{
 		vao:       vao,
@@ -118,22 +297,75 @@ func (r *RendererGL) CreateMesh(desc core.MeshDesc) (core.Mesh, error) {
 		stride:    int32(desc.Layout.Stride),
 		vCapBytes: vSize,
 		iCapBytes: iSize,
+
+		instVBO:      instVBO,
+		instCapBytes: instSize,
+
+		layout:     desc.Layout,
+		instLayout: desc.InstanceLayout,
+
+		usage: desc.Usage,
+		vRing: vRing,
+		iRing: iRing,
+	}
+	if stream {
+		r.bindVAO(m.vao)
+		m.writeStream(vertices, desc.Indices)
+		r.bindVAO(0)
 	}
 	return m, nil
 }
 
 func (r *RendererGL) CreatePipeline(desc core.PipelineDesc) (core.Pipeline, error) {
-	prog, err := makeProgram(desc.VertexSource, desc.FragmentSource)
+	defer r.checkError("CreatePipeline")
+	prog, err := makeProgram(desc)
 	if err != nil {
 		return nil, err
 	}
-	return &pipeGL{prog: prog, depthTest: desc.DepthTest, blend: desc.Blend}, nil
+
+	p := &pipeGL{
+		prog: prog, depthTest: desc.DepthTest, blend: desc.Blend,
+		name:    pipelineName(desc),
+		units:   map[int32]int32{},
+		checked: map[*meshGL]bool{},
+		warned:  map[string]bool{},
+	}
+	p.info, p.uniforms = reflectProgram(prog)
+	r.bindBlocks(p)
+	if desc.Label != "" {
+		r.label(gl.PROGRAM, prog, desc.Label)
+	} else {
+		r.label(gl.PROGRAM, prog, p.name)
+	}
+
+	if len(desc.Layout.Attributes) > 0 {
+		if err := p.info.ValidateLayout(desc.Layout); err != nil {
+			gl.DeleteProgram(prog)
+			return nil, fmt.Errorf("pipeline %s: %w", p.name, err)
+		}
+	}
+	return p, nil
+}
+
+func (r *RendererGL) PipelineInfo(p core.Pipeline) core.PipelineInfo {
+	return p.(*pipeGL).info
 }
 
 func (r *RendererGL) CreateTexture(desc core.TextureDesc) (core.Texture, error) {
+	defer r.checkError("CreateTexture")
+	return r.newTexture(desc, bytesPtr(desc.Pixels))
+}
+
+// newTexture creates a texture from desc with its level 0 read from pixels
+// (nil => uninitialized storage). It stays bound to unit 0.
+func (r *RendererGL) newTexture(desc core.TextureDesc, pixels unsafe.Pointer) (*texGL, error) {
+	internal, err := r.internalFormat(desc)
+	if err != nil {
+		return nil, err
+	}
 	var id uint32
 	gl.GenTextures(1, &id)
-	gl.BindTexture(gl.TEXTURE_2D, id)
+	r.bindTexture(0, id)
 
 	// Params
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, toGLFilter(desc.MinFilter))
@@ -142,34 +374,49 @@ func (r *RendererGL) CreateTexture(desc core.TextureDesc) (core.Texture, error)
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, toGLWrap(desc.WrapV))
 
 	// Data
-	if desc.Format != core.TextureRGBA8 {
-		return nil, fmt.Errorf("only RGBA8 supported for now")
-	}
-	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA8, int32(desc.Width), int32(desc.Height), 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(desc.Pixels))
-
-	gl.BindTexture(gl.TEXTURE_2D, 0)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, internal, int32(desc.Width), int32(desc.Height), 0, gl.RGBA, gl.UNSIGNED_BYTE, pixels)
+	r.label(gl.TEXTURE, id, desc.Label)
 	return &texGL{id: id, w: desc.Width, h: desc.Height}, nil
 }
 
+// internalFormat picks the GL storage for desc. With LinearColor, RGBA8 color
+// textures are stored as sRGB so sampling returns linear values.
+func (r *RendererGL) internalFormat(desc core.TextureDesc) (int32, error) {
+	switch desc.Format {
+	case core.TextureRGBA8:
+		if r.linear && !desc.NonColor {
+			return gl.SRGB8_ALPHA8, nil
+		}
+		return gl.RGBA8, nil
+	case core.TextureSRGBA8:
+		return gl.SRGB8_ALPHA8, nil
+	}
+	return 0, fmt.Errorf("unsupported texture format %d", desc.Format)
+}
+
 func (r *RendererGL) GPUVendor() string   { return r.vendor }
 func (r *RendererGL) GPURenderer() string { return r.renderer }
 func (r *RendererGL) GPUVersion() string  { return r.version }
 
 func (r *RendererGL) UpdateMesh(mesh core.Mesh, vertices []float32, indices []uint32) error {
+	return r.UpdateMeshData(mesh, float32Bytes(vertices), indices)
+}
+
+func (r *RendererGL) UpdateMeshData(mesh core.Mesh, vertices []byte, indices []uint32) error {
+	defer r.checkError("UpdateMesh")
 	m := mesh.(*meshGL)
 
-	gl.BindVertexArray(m.vao)
+	r.bindVAO(m.vao)
+	if m.usage == core.MeshStream {
+		m.writeStream(vertices, indices)
+		return nil
+	}
 
 	// vertex buffer
-	vSize := len(vertices) * 4
+	vSize := len(vertices)
 	if vSize > 0 {
 		gl.BindBuffer(gl.ARRAY_BUFFER, m.vbo)
-		if vSize > m.vCapBytes {
-			gl.BufferData(gl.ARRAY_BUFFER, vSize, gl.Ptr(vertices), gl.DYNAMIC_DRAW)
-			m.vCapBytes = vSize
-		} else {
-			gl.BufferSubData(gl.ARRAY_BUFFER, 0, vSize, gl.Ptr(vertices))
-		}
+		m.upload(gl.ARRAY_BUFFER, vertices, &m.vCapBytes)
 		if m.stride > 0 {
 			m.nVtx = vSize / int(m.stride)
 		}
@@ -182,12 +429,7 @@ func (r *RendererGL) UpdateMesh(mesh core.Mesh, vertices []float32, indices []ui
 		iSize := len(indices) * 4
 		gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, m.ebo)
 		if iSize > 0 {
-			if iSize > m.iCapBytes {
-				gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, iSize, gl.Ptr(indices), gl.DYNAMIC_DRAW)
-				m.iCapBytes = iSize
-			} else {
-				gl.BufferSubData(gl.ELEMENT_ARRAY_BUFFER, 0, iSize, gl.Ptr(indices))
-			}
+			m.upload(gl.ELEMENT_ARRAY_BUFFER, uint32Bytes(indices), &m.iCapBytes)
 			m.nIdx = len(indices)
 		} else {
 			m.nIdx = 0
@@ -195,13 +437,178 @@ func (r *RendererGL) UpdateMesh(mesh core.Mesh, vertices []float32, indices []ui
 	} else {
 		m.nIdx = 0
 	}
+	return nil
+}
+
+func (r *RendererGL) UpdateInstances(mesh core.Mesh, instances []float32) error {
+	return r.UpdateInstanceData(mesh, float32Bytes(instances))
+}
+
+func (r *RendererGL) UpdateInstanceData(mesh core.Mesh, instances []byte) error {
+	defer r.checkError("UpdateInstances")
+	m := mesh.(*meshGL)
+	if m.instVBO == 0 {
+		return fmt.Errorf("mesh has no instance layout")
+	}
 
-	gl.BindVertexArray(0)
+	size := len(instances)
+	if size == 0 {
+		return nil
+	}
+	gl.BindBuffer(gl.ARRAY_BUFFER, m.instVBO)
+	m.upload(gl.ARRAY_BUFFER, instances, &m.instCapBytes) // stream meshes orphan instances
+	gl.BindBuffer(gl.ARRAY_BUFFER, 0)
 	return nil
 }
 
 // ------- Helpers -------
 
+// upload replaces the contents of the buffer bound to target. Dynamic meshes
+// orphan the old storage first so the driver needn't wait for draws reading
+// it; static meshes overwrite in place.
+func (m *meshGL) upload(target uint32, data []byte, capBytes *int) {
+	usage := uint32(gl.DYNAMIC_DRAW)
+	if m.usage == core.MeshStatic {
+		usage = gl.STATIC_DRAW
+	}
+	switch {
+	case len(data) > *capBytes:
+		gl.BufferData(target, len(data), gl.Ptr(data), usage)
+		*capBytes = len(data)
+	case m.usage == core.MeshStatic:
+		gl.BufferSubData(target, 0, len(data), gl.Ptr(data))
+	default:
+		gl.BufferData(target, *capBytes, nil, usage)
+		gl.BufferSubData(target, 0, len(data), gl.Ptr(data))
+	}
+}
+
+// writeStream appends an update to the rings of a stream mesh (VAO bound).
+func (m *meshGL) writeStream(vertices []byte, indices []uint32) {
+	m.nVtx, m.vBase = 0, 0
+	if len(vertices) > 0 {
+		align := max(int(m.stride), 1) // whole vertices, so draws can use a base vertex
+		gl.BindBuffer(gl.ARRAY_BUFFER, m.vbo)
+		m.vBase = m.vRing.write(vertices, align) / align
+		if m.stride > 0 {
+			m.nVtx = len(vertices) / int(m.stride)
+		}
+	}
+
+	m.nIdx, m.iOffset = 0, 0
+	if m.ebo != 0 && len(indices) > 0 {
+		gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, m.ebo)
+		m.iOffset = m.iRing.write(uint32Bytes(indices), 4)
+		m.nIdx = len(indices)
+	}
+}
+
+func queryCaps() core.Caps {
+	getInt := func(pname uint32) int {
+		var v int32
+		gl.GetIntegerv(pname, &v)
+		return int(v)
+	}
+	c := core.Caps{
+		MaxTextureUnits:         getInt(gl.MAX_TEXTURE_IMAGE_UNITS),
+		MaxCombinedTextureUnits: getInt(gl.MAX_COMBINED_TEXTURE_IMAGE_UNITS),
+		MaxTextureSize:          getInt(gl.MAX_TEXTURE_SIZE),
+		MaxSamples:              getInt(gl.MAX_SAMPLES),
+		MaxVertexAttribs:        getInt(gl.MAX_VERTEX_ATTRIBS),
+		MaxUniformBlockSize:     getInt(gl.MAX_UNIFORM_BLOCK_SIZE),
+		MaxUniformBindings:      getInt(gl.MAX_UNIFORM_BUFFER_BINDINGS),
+		Formats:                 []core.TextureFormat{core.TextureRGBA8, core.TextureSRGBA8},
+	}
+	n := getInt(gl.NUM_EXTENSIONS)
+	for i := 0; i < n; i++ {
+		c.Extensions = append(c.Extensions, gl.GoStr(gl.GetStringi(gl.EXTENSIONS, uint32(i))))
+	}
+	return c
+}
+
+// setupAttribs declares the attributes of layout for the buffer currently
+// bound to ARRAY_BUFFER. Instance layouts step once per instance unless an
+// attribute asks for a larger divisor. Types must have been checked with
+// toGLAttribType beforehand.
+func setupAttribs(layout core.VertexLayout, instanced bool) {
+	for _, a := range layout.Attributes {
+		loc := uint32(a.Location)
+		typ, _ := toGLAttribType(a.Type)
+		gl.EnableVertexAttribArray(loc)
+		if a.Type.IsInteger() && !a.Normalized {
+			gl.VertexAttribIPointerWithOffset(loc, int32(a.Size), typ, int32(layout.Stride), uintptr(a.Offset))
+		} else {
+			gl.VertexAttribPointerWithOffset(loc, int32(a.Size), typ, a.Normalized, int32(layout.Stride), uintptr(a.Offset))
+		}
+
+		divisor := a.Divisor
+		if instanced && divisor == 0 {
+			divisor = 1
+		}
+		if divisor > 0 {
+			gl.VertexAttribDivisor(loc, uint32(divisor))
+		}
+	}
+}
+
+func toGLAttribType(t core.AttribType) (uint32, bool) {
+	switch t {
+	case core.AttribFloat32:
+		return gl.FLOAT, true
+	case core.AttribFloat16:
+		return gl.HALF_FLOAT, true
+	case core.AttribInt8:
+		return gl.BYTE, true
+	case core.AttribUint8:
+		return gl.UNSIGNED_BYTE, true
+	case core.AttribInt16:
+		return gl.SHORT, true
+	case core.AttribUint16:
+		return gl.UNSIGNED_SHORT, true
+	case core.AttribInt32:
+		return gl.INT, true
+	case core.AttribUint32:
+		return gl.UNSIGNED_INT, true
+	}
+	return 0, false
+}
+
+// uint32Bytes reinterprets v as raw bytes without copying.
+func uint32Bytes(v []uint32) []byte {
+	if len(v) == 0 {
+		return nil
+	}
+	return unsafe.Slice((*byte)(unsafe.Pointer(&v[0])), len(v)*4)
+}
+
+// float32Bytes reinterprets v as raw bytes without copying.
+func float32Bytes(v []float32) []byte {
+	if len(v) == 0 {
+		return nil
+	}
+	return unsafe.Slice((*byte)(unsafe.Pointer(&v[0])), len(v)*4)
+}
+
+// bytesPtr returns a GL pointer to b, or nil to only allocate storage.
+func bytesPtr(b []byte) unsafe.Pointer {
+	if len(b) == 0 {
+		return nil
+	}
+	return gl.Ptr(b)
+}
+
+func (r *RendererGL) CreateSampler(desc core.SamplerDesc) (core.Sampler, error) {
+	defer r.checkError("CreateSampler")
+	s := &samplerGL{}
+	gl.GenSamplers(1, &s.id)
+	gl.SamplerParameteri(s.id, gl.TEXTURE_MIN_FILTER, toGLFilter(desc.MinFilter))
+	gl.SamplerParameteri(s.id, gl.TEXTURE_MAG_FILTER, toGLFilter(desc.MagFilter))
+	gl.SamplerParameteri(s.id, gl.TEXTURE_WRAP_S, toGLWrap(desc.WrapU))
+	gl.SamplerParameteri(s.id, gl.TEXTURE_WRAP_T, toGLWrap(desc.WrapV))
+	r.label(gl.SAMPLER, s.id, desc.Label)
+	return s, nil
+}
+
 func toGLFilter(f string) int32 {
 	switch f {
 	case "linear":
@@ -220,77 +627,123 @@ func toGLWrap(w string) int32 {
 }
 
 func (r *RendererGL) Draw(cmd core.DrawCmd) {
+	defer r.checkError("Draw")
 	p := cmd.Pipe.(*pipeGL)
 	m := cmd.Mesh.(*meshGL)
 
 	// state
-	if p.depthTest {
-		gl.Enable(gl.DEPTH_TEST)
-	} else {
-		gl.Disable(gl.DEPTH_TEST)
+	r.setDepthTest(p.depthTest)
+	r.setBlend(p.blend)
+	r.useProgram(p.prog)
+
+	if !p.checked[m] {
+		p.checked[m] = true
+		if err := p.info.ValidateLayout(m.layout, m.instLayout); err != nil {
+			log.Printf("gl: pipeline %s: mesh layout mismatch:\n%v", p.name, err)
+		}
+		p.checkSamplers(cmd.Textures)
 	}
-	if p.blend {
-		gl.Enable(gl.BLEND)
-		gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
-	} else {
-		gl.Disable(gl.BLEND)
+	if !p.blocksOK {
+		p.blocksOK = true
+		r.checkBlocks(p)
 	}
 
-	gl.UseProgram(p.prog)
-
-	// uniforms (minimal)
 	for name, v := range cmd.Uniforms {
-		switch name {
-		case "uMVP":
-			if mat, ok := v.([16]float32); ok {
-				loc := gl.GetUniformLocation(p.prog, gl.Str("uMVP\x00"))
-				if loc >= 0 {
-					gl.UniformMatrix4fv(loc, 1, false, &mat[0])
-				}
-			}
-		case "uVP":
-			if mat, ok := v.([16]float32); ok {
-				loc := gl.GetUniformLocation(p.prog, gl.Str("uVP\x00"))
-				if loc >= 0 {
-					gl.UniformMatrix4fv(loc, 1, false, &mat[0])
-				}
-			}
+		u, ok := p.uniforms[name]
+		if !ok {
+			p.warnOnce(name, "uniform %q is not an active uniform", name)
+			continue
+		}
+		if !setUniform(u, v) {
+			p.warnOnce(name, "uniform %q (%s) can't be set from %T", name, u.typ, v)
 		}
 	}
 
-	// samplers: bind textures to units 0..N in stable order of iteration
-	unit := 0
-	for name, t := range cmd.Samplers {
-		tx := t.(*texGL)
-		gl.ActiveTexture(uint32(gl.TEXTURE0 + unit))
-		gl.BindTexture(gl.TEXTURE_2D, tx.id)
+	// textures: Textures[i] goes to unit i
+	if len(cmd.Textures) > r.caps.MaxCombinedTextureUnits {
+		p.warnOnce("units", "%d textures bound, device has %d units", len(cmd.Textures), r.caps.MaxCombinedTextureUnits)
+	}
+	for unit, b := range cmd.Textures[:min(len(cmd.Textures), r.caps.MaxCombinedTextureUnits)] {
+		var id, sampler uint32
+		if b.Texture != nil {
+			id = b.Texture.(*texGL).id
+		}
+		if b.Sampler != nil {
+			sampler = b.Sampler.(*samplerGL).id
+		}
+		r.bindTexture(unit, id)
+		r.bindSampler(unit, sampler)
 
-		// assign sampler uniform to this unit
-		loc := gl.GetUniformLocation(p.prog, gl.Str((name + "\x00")))
-		if loc >= 0 {
-			gl.Uniform1i(loc, int32(unit))
+		if b.Uniform == "" {
+			continue
+		}
+		if u, ok := p.uniforms[b.Uniform]; ok && u.typ.IsSampler() {
+			r.setSamplerUnit(p, u.loc, unit)
+		} else {
+			p.warnOnce(b.Uniform, "sampler %q is not an active sampler uniform", b.Uniform)
 		}
-		unit++
 	}
-	// NOTE: we don't unbind here; next draw will overwrite bindings
+	// NOTE: we don't unbind here; the next draw only rebinds what differs
 
-	gl.BindVertexArray(m.vao)
+	r.bindVAO(m.vao)
+	r.stats.DrawCalls++
 	if m.ebo != 0 && m.nIdx > 0 {
-		gl.DrawElements(gl.TRIANGLES, int32(m.nIdx), gl.UNSIGNED_INT, unsafe.Pointer(uintptr(0)))
+		switch {
+		case cmd.InstanceCount > 0:
+			gl.DrawElementsInstancedBaseVertex(gl.TRIANGLES, int32(m.nIdx), gl.UNSIGNED_INT, gl.PtrOffset(m.iOffset), int32(cmd.InstanceCount), int32(m.vBase))
+		case m.vBase != 0 || m.iOffset != 0:
+			gl.DrawElementsBaseVertexWithOffset(gl.TRIANGLES, int32(m.nIdx), gl.UNSIGNED_INT, uintptr(m.iOffset), int32(m.vBase))
+		default:
+			gl.DrawElements(gl.TRIANGLES, int32(m.nIdx), gl.UNSIGNED_INT, nil)
+		}
 	} else {
 		count := m.nVtx
 		if cmd.Count > 0 {
 			count = cmd.Count
 		}
-		gl.DrawArrays(gl.TRIANGLES, 0, int32(count))
+		if cmd.InstanceCount > 0 {
+			gl.DrawArraysInstanced(gl.TRIANGLES, int32(m.vBase), int32(count), int32(cmd.InstanceCount))
+		} else {
+			gl.DrawArrays(gl.TRIANGLES, int32(m.vBase), int32(count))
+		}
+	}
+	// program and VAO stay bound; the next draw skips them if unchanged
+}
+
+// ------- Bound state -------
+
+// warnOnce logs a pipeline warning the first time key is seen.
+func (p *pipeGL) warnOnce(key, format string, args ...any) {
+	if p.warned[key] {
+		return
+	}
+	p.warned[key] = true
+	log.Printf("gl: pipeline %s: %s", p.name, fmt.Sprintf(format, args...))
+}
+
+// checkSamplers warns about sampler uniforms none of whose elements are bound
+// by the first draw; they silently sample texture unit 0.
+func (p *pipeGL) checkSamplers(bound []core.TextureBinding) {
+	for _, u := range p.info.Uniforms {
+		if !u.Type.IsSampler() || u.Location < 0 {
+			continue
+		}
+		found := false
+		for _, b := range bound {
+			if name := b.Uniform; name == u.Name || strings.HasPrefix(name, u.Name+"[") {
+				found = true
+				break
+			}
+		}
+		if !found {
+			p.warnOnce(u.Name, "sampler %q is never bound", u.Name)
+		}
 	}
-	gl.BindVertexArray(0)
-	gl.UseProgram(0)
 }
 
 // ------------ shader helpers ------------
 
-func makeShader(src string, shaderType uint32) (uint32, error) {
+func makeShader(src string, shaderType uint32, files []string, fallback string) (uint32, error) {
 	sh := gl.CreateShader(shaderType)
 	csrc, free := gl.Strs(src)
 	defer free()
@@ -302,19 +755,20 @@ func makeShader(src string, shaderType uint32) (uint32, error) {
 	if status == gl.FALSE {
 		var logLen int32
 		gl.GetShaderiv(sh, gl.INFO_LOG_LENGTH, &logLen)
-		log := strings.Repeat("\x00", int(logLen))
-		gl.GetShaderInfoLog(sh, logLen, nil, gl.Str(log))
-		return 0, fmt.Errorf("shader compile error: %s", log)
+		infoLog := strings.Repeat("\x00", int(logLen))
+		gl.GetShaderInfoLog(sh, logLen, nil, gl.Str(infoLog))
+		gl.DeleteShader(sh)
+		return 0, fmt.Errorf("shader compile error in %s:\n%s", sourceName(files, fallback), formatShaderLog(infoLog, files, fallback, src))
 	}
 	return sh, nil
 }
 
-func makeProgram(vsSrc, fsSrc string) (uint32, error) {
-	vs, err := makeShader(vsSrc, gl.VERTEX_SHADER)
+func makeProgram(desc core.PipelineDesc) (uint32, error) {
+	vs, err := makeShader(desc.VertexSource, gl.VERTEX_SHADER, desc.VertexFiles, "vertex shader")
 	if err != nil {
 		return 0, err
 	}
-	fs, err := makeShader(fsSrc, gl.FRAGMENT_SHADER)
+	fs, err := makeShader(desc.FragmentSource, gl.FRAGMENT_SHADER, desc.FragmentFiles, "fragment shader")
 	if err != nil {
 		gl.DeleteShader(vs)
 		return 0, err
@@ -332,9 +786,10 @@ func makeProgram(vsSrc, fsSrc string) (uint32, error) {
 	if status == gl.FALSE {
 		var logLen int32
 		gl.GetProgramiv(prog, gl.INFO_LOG_LENGTH, &logLen)
-		log := strings.Repeat("\x00", int(logLen))
-		gl.GetProgramInfoLog(prog, logLen, nil, gl.Str(log))
-		return 0, fmt.Errorf("program link error: %s", log)
+		infoLog := strings.Repeat("\x00", int(logLen))
+		gl.GetProgramInfoLog(prog, logLen, nil, gl.Str(infoLog))
+		gl.DeleteProgram(prog)
+		return 0, fmt.Errorf("program link error (%s):\n%s", pipelineName(desc), strings.TrimRight(infoLog, "\x00\n "))
 	}
 	return prog, nil
 }
@@ -0,0 +1,77 @@
+package glbackend
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hubastard/grove/engine/core"
+)
+
+// Driver info logs reference "source-string:line" in a few dialects:
+//
+//	NVIDIA:      0(12) : error C0000: syntax error
+//	Mesa:        0:12(5): error: `foo' undeclared
+//	AMD/Apple:   ERROR: 0:12: 'foo' : undeclared identifier
+var shaderLogLine = regexp.MustCompile(`^(?:(ERROR|WARNING):\s*)?(\d+)(?::(\d+)|\((\d+)\))(?:\(\d+\))?\s*:\s*(.*)$`)
+
+// formatShaderLog rewrites a compiler info log so each message points at
+// "file:line". files maps GLSL source-string numbers to names; fallback is
+// used when a number has no name. When src has no #line directives the
+// offending source line is quoted under the message.
+func formatShaderLog(infoLog string, files []string, fallback, src string) string {
+	var srcLines []string
+	if src != "" && !strings.Contains(src, "#line") {
+		srcLines = strings.Split(src, "\n")
+	}
+
+	var b strings.Builder
+	for _, raw := range strings.Split(strings.TrimRight(infoLog, "\x00\n "), "\n") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+
+		m := shaderLogLine.FindStringSubmatch(raw)
+		if m == nil {
+			b.WriteString(raw)
+			continue
+		}
+		srcNum, _ := strconv.Atoi(m[2])
+		line, _ := strconv.Atoi(m[3] + m[4])
+		msg := m[5]
+		if m[1] != "" {
+			msg = strings.ToLower(m[1]) + ": " + msg
+		}
+
+		file := fallback
+		if srcNum < len(files) && files[srcNum] != "" {
+			file = files[srcNum]
+		} else if srcNum > 0 {
+			file = fmt.Sprintf("%s#%d", fallback, srcNum)
+		}
+		fmt.Fprintf(&b, "%s:%d: %s", file, line, msg)
+
+		if srcNum == 0 && line >= 1 && line <= len(srcLines) {
+			fmt.Fprintf(&b, "\n\t%s", strings.TrimSpace(srcLines[line-1]))
+		}
+	}
+	return b.String()
+}
+
+// sourceName returns the display name of a shader stage for messages.
+func sourceName(files []string, fallback string) string {
+	if len(files) > 0 && files[0] != "" {
+		return files[0]
+	}
+	return fallback
+}
+
+// pipelineName names a pipeline after its shader sources for messages.
+func pipelineName(desc core.PipelineDesc) string {
+	return sourceName(desc.VertexFiles, "vertex shader") + "+" + sourceName(desc.FragmentFiles, "fragment shader")
+}
@@ -0,0 +1,43 @@
+package glbackend
+
+import "testing"
+
+func TestFormatShaderLog(t *testing.T) {
+	files := []string{"sprite.frag", "common.glsl"}
+	tests := []struct {
+		name string
+		log  string
+		src  string
+		want string
+	}{
+		{"nvidia", "0(12) : error C0000: syntax error, unexpected '}'\n", "",
+			"sprite.frag:12: error C0000: syntax error, unexpected '}'"},
+		{"mesa", "0:7(5): error: `foo' undeclared\n", "",
+			"sprite.frag:7: error: `foo' undeclared"},
+		{"amd", "ERROR: 0:3: 'foo' : undeclared identifier\x00", "",
+			"sprite.frag:3: error: 'foo' : undeclared identifier"},
+		{"warning", "WARNING: 1:4: extension not supported", "",
+			"common.glsl:4: warning: extension not supported"},
+		{"included file", "1(9) : error C1008: undefined variable", "",
+			"common.glsl:9: error C1008: undefined variable"},
+		{"unnamed source string", "5:2(1): error: bad", "",
+			"shader#5:2: error: bad"},
+		{"unknown format kept", "Link failed: too many varyings\n\n", "",
+			"Link failed: too many varyings"},
+		{"several lines", "0:1(1): error: a\n  0:2(1): error: b  \n", "",
+			"sprite.frag:1: error: a\nsprite.frag:2: error: b"},
+		{"quotes the source line", "0:2(3): error: x undeclared", "#version 330 core\n  x = 1;\n",
+			"sprite.frag:2: error: x undeclared\n\tx = 1;"},
+		{"no quote with #line", "0:2(3): error: x undeclared", "#version 330 core\n#line 1\nx = 1;\n",
+			"sprite.frag:2: error: x undeclared"},
+		{"no quote out of range", "0:9(3): error: x undeclared", "#version 330 core\n",
+			"sprite.frag:9: error: x undeclared"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := formatShaderLog(tc.log, files, "shader", tc.src); got != tc.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package glbackend
+
+import "github.com/go-gl/gl/v3.3-core/gl"
+
+// boundState mirrors the GL bindings Draw depends on, so consecutive draws
+// only issue the calls that change something. Every cached call counts as
+// a state change or a skip in core.RenderStats.
+type boundState struct {
+	prog, vao    uint32
+	depth, blend int8     // -1 unknown, 0 off, 1 on
+	unit         int      // active texture unit, -1 unknown
+	textures     []uint32 // TEXTURE_2D per unit
+	samplers     []uint32 // sampler object per unit
+}
+
+func (r *RendererGL) count(changed bool) bool {
+	if changed {
+		r.stats.StateChanges++
+	} else {
+		r.stats.StateSkipped++
+	}
+	return changed
+}
+
+func (r *RendererGL) useProgram(prog uint32) {
+	if r.count(r.state.prog != prog) {
+		gl.UseProgram(prog)
+		r.state.prog = prog
+	}
+}
+
+func (r *RendererGL) bindVAO(vao uint32) {
+	if r.count(r.state.vao != vao) {
+		gl.BindVertexArray(vao)
+		r.state.vao = vao
+	}
+}
+
+func (r *RendererGL) setDepthTest(on bool) { r.setCap(gl.DEPTH_TEST, &r.state.depth, on) }
+
+func (r *RendererGL) setBlend(on bool) {
+	if r.setCap(gl.BLEND, &r.state.blend, on) && on {
+		gl.BlendFunc(gl.SRC_ALPHA, gl.ONE_MINUS_SRC_ALPHA)
+	}
+}
+
+// setCap enables/disables a GL capability if cur says it differs. It reports
+// whether a call was made.
+func (r *RendererGL) setCap(capability uint32, cur *int8, on bool) bool {
+	want := int8(0)
+	if on {
+		want = 1
+	}
+	if !r.count(*cur != want) {
+		return false
+	}
+	if on {
+		gl.Enable(capability)
+	} else {
+		gl.Disable(capability)
+	}
+	*cur = want
+	return true
+}
+
+func (r *RendererGL) activeUnit(unit int) {
+	if r.state.unit != unit {
+		gl.ActiveTexture(uint32(gl.TEXTURE0 + unit))
+		r.state.unit = unit
+	}
+}
+
+// bindTexture binds a 2D texture to unit (leaving unit active if it did).
+func (r *RendererGL) bindTexture(unit int, id uint32) {
+	if r.count(r.state.textures[unit] != id) {
+		r.activeUnit(unit)
+		gl.BindTexture(gl.TEXTURE_2D, id)
+		r.state.textures[unit] = id
+	}
+}
+
+func (r *RendererGL) bindSampler(unit int, id uint32) {
+	if r.count(r.state.samplers[unit] != id) {
+		gl.BindSampler(uint32(unit), id)
+		r.state.samplers[unit] = id
+	}
+}
+
+// setSamplerUnit points a sampler uniform of p (which must be in use) at unit.
+func (r *RendererGL) setSamplerUnit(p *pipeGL, loc int32, unit int) {
+	if cur, ok := p.units[loc]; r.count(!ok || cur != int32(unit)) {
+		gl.Uniform1i(loc, int32(unit))
+		p.units[loc] = int32(unit)
+	}
+}
+
+// deleteTexture deletes a texture and forgets it in the units it was bound to
+// (GL unbinds it; a new texture may reuse the name).
+func (r *RendererGL) deleteTexture(id uint32) {
+	gl.DeleteTextures(1, &id)
+	for unit, bound := range r.state.textures {
+		if bound == id {
+			r.state.textures[unit] = 0
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package glbackend
+
+import (
+	"time"
+	"unsafe"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// Stream meshes (core.MeshStream) write each update into the next free
+// range of a buffer several times larger than one update, with unsynchronized
+// maps. Draws then read at an offset, so a write never targets memory a
+// queued draw still uses. Each range gets a fence once the ring moves past
+// it; the writer only waits when it laps a range the GPU hasn't finished.
+
+// streamCopies is how many full-size updates fit in a ring.
+const streamCopies = 3
+
+type ringSpan struct {
+	start, end int
+	fence      uintptr // 0 until the ring moves on
+}
+
+type streamRing struct {
+	target uint32 // gl.ARRAY_BUFFER or gl.ELEMENT_ARRAY_BUFFER
+	size   int
+	head   int
+	spans  []ringSpan // in flight, oldest first
+	waits  *int       // counts writes that had to wait on a fence
+}
+
+// newStreamRing allocates a ring for updates of up to capBytes in the buffer
+// bound to target.
+func newStreamRing(target uint32, capBytes int, waits *int) *streamRing {
+	s := &streamRing{target: target, waits: waits}
+	s.alloc(max(capBytes, 256) * streamCopies)
+	return s
+}
+
+func (s *streamRing) alloc(size int) {
+	s.release()
+	s.size = size
+	s.head = 0
+	gl.BufferData(s.target, size, nil, gl.STREAM_DRAW) // orphans the old storage
+}
+
+// write copies data into the ring (buffer bound to target) and returns its
+// byte offset, aligned to align.
+func (s *streamRing) write(data []byte, align int) int {
+	n := len(data)
+	if n*streamCopies > s.size {
+		s.alloc(n * streamCopies * 2)
+	}
+
+	// close the previous update: every draw reading it has been issued
+	if k := len(s.spans); k > 0 && s.spans[k-1].fence == 0 {
+		s.spans[k-1].fence = gl.FenceSync(gl.SYNC_GPU_COMMANDS_COMPLETE, 0)
+	}
+
+	start := (s.head + align - 1) / align * align
+	if start+n > s.size {
+		start = 0
+	}
+	end := start + n
+	s.retire(start, end)
+
+	ptr := gl.MapBufferRange(s.target, start, n, gl.MAP_WRITE_BIT|gl.MAP_UNSYNCHRONIZED_BIT|gl.MAP_INVALIDATE_RANGE_BIT)
+	if ptr == nil {
+		// mapping failed (lost context?): fall back to a synchronized upload
+		gl.BufferSubData(s.target, start, n, gl.Ptr(data))
+	} else {
+		copy(unsafe.Slice((*byte)(ptr), n), data)
+		gl.UnmapBuffer(s.target)
+	}
+
+	s.spans = append(s.spans, ringSpan{start: start, end: end})
+	s.head = end
+	return start
+}
+
+// retire drops the in-flight spans up to the newest one overlapping
+// [start, end), waiting for its fence if the GPU hasn't passed it yet.
+// Fences signal in order, so older spans are done by then too.
+func (s *streamRing) retire(start, end int) {
+	last := -1
+	for i, sp := range s.spans {
+		if sp.start < end && start < sp.end {
+			last = i
+		}
+	}
+	if last < 0 {
+		return
+	}
+	if f := s.spans[last].fence; f != 0 && gl.ClientWaitSync(f, 0, 0) == gl.TIMEOUT_EXPIRED {
+		*s.waits++
+		gl.ClientWaitSync(f, gl.SYNC_FLUSH_COMMANDS_BIT, uint64(time.Second))
+	}
+	for _, sp := range s.spans[:last+1] {
+		if sp.fence != 0 {
+			gl.DeleteSync(sp.fence)
+		}
+	}
+	s.spans = append(s.spans[:0], s.spans[last+1:]...)
+}
+
+// release drops all fences; the buffer storage is about to be replaced.
+func (s *streamRing) release() {
+	for _, sp := range s.spans {
+		if sp.fence != 0 {
+			gl.DeleteSync(sp.fence)
+		}
+	}
+	s.spans = s.spans[:0]
+}
@@ -0,0 +1,136 @@
+package glbackend
+
+import (
+	"fmt"
+	"log"
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/hubastard/grove/engine/core"
+)
+
+// Async uploads stage pixels in a mapped pixel buffer object. Once submitted,
+// the next BeginFrame unmaps it and starts glTexSubImage2D from the PBO,
+// which returns without waiting for the copy; a fence then tells a later
+// frame when the texture is ready.
+
+type textureUpload struct {
+	tex     *texGL
+	label   string
+	pbo     uint32
+	pixels  []byte // mapped PBO memory; never reset, Pixels hides it once submitted
+	onReady func(core.Texture)
+
+	submitted atomic.Bool
+	ready     atomic.Bool
+	err       atomic.Pointer[error] // set if the staged pixels were lost
+	fence     uintptr               // set once the transfer is queued
+}
+
+func (u *textureUpload) Texture() core.Texture { return u.tex }
+func (u *textureUpload) Submit()               { u.submitted.Store(true) }
+func (u *textureUpload) Ready() bool           { return u.ready.Load() }
+
+// Pixels returns nil once submitted: BeginFrame may unmap the memory at any
+// time after that.
+func (u *textureUpload) Pixels() []byte {
+	if u.submitted.Load() {
+		return nil
+	}
+	return u.pixels
+}
+
+func (u *textureUpload) Err() error {
+	if err := u.err.Load(); err != nil {
+		return *err
+	}
+	return nil
+}
+
+// CreateTextureAsync reserves a texture and returns its staging memory. When
+// desc.Pixels is set they are copied into it on a worker goroutine and
+// submitted automatically. onReady (optional) runs on the render thread, in
+// BeginFrame, once the texture can be drawn.
+func (r *RendererGL) CreateTextureAsync(desc core.TextureDesc, onReady func(core.Texture)) (core.TextureUpload, error) {
+	defer r.checkError("CreateTextureAsync")
+	size := desc.Width * desc.Height * 4
+	if size <= 0 {
+		return nil, fmt.Errorf("CreateTextureAsync: invalid size %dx%d", desc.Width, desc.Height)
+	}
+	if desc.Pixels != nil && len(desc.Pixels) != size {
+		return nil, fmt.Errorf("CreateTextureAsync: %d bytes of pixels for %dx%d RGBA8", len(desc.Pixels), desc.Width, desc.Height)
+	}
+	tex, err := r.newTexture(desc, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	u := &textureUpload{tex: tex, label: desc.Label, onReady: onReady}
+	gl.GenBuffers(1, &u.pbo)
+	gl.BindBuffer(gl.PIXEL_UNPACK_BUFFER, u.pbo)
+	gl.BufferData(gl.PIXEL_UNPACK_BUFFER, size, nil, gl.STREAM_DRAW)
+	ptr := gl.MapBufferRange(gl.PIXEL_UNPACK_BUFFER, 0, size, gl.MAP_WRITE_BIT|gl.MAP_INVALIDATE_BUFFER_BIT)
+	gl.BindBuffer(gl.PIXEL_UNPACK_BUFFER, 0)
+	if ptr == nil {
+		gl.DeleteBuffers(1, &u.pbo)
+		r.deleteTexture(tex.id)
+		return nil, fmt.Errorf("CreateTextureAsync: could not map %d byte staging buffer", size)
+	}
+	u.pixels = unsafe.Slice((*byte)(ptr), size)
+	if desc.Label != "" {
+		r.label(gl.BUFFER, u.pbo, desc.Label+".staging")
+	}
+	r.uploads = append(r.uploads, u)
+
+	if desc.Pixels != nil {
+		go func() {
+			copy(u.pixels, desc.Pixels)
+			u.Submit()
+		}()
+	}
+	return u, nil
+}
+
+// pollUploads starts submitted transfers and completes finished ones.
+func (r *RendererGL) pollUploads() {
+	var done []*textureUpload
+	pending := r.uploads[:0]
+	for _, u := range r.uploads {
+		switch {
+		case u.fence == 0 && u.submitted.Load():
+			gl.BindBuffer(gl.PIXEL_UNPACK_BUFFER, u.pbo)
+			if !gl.UnmapBuffer(gl.PIXEL_UNPACK_BUFFER) {
+				// the staging memory was lost (e.g. a display mode change)
+				gl.BindBuffer(gl.PIXEL_UNPACK_BUFFER, 0)
+				gl.DeleteBuffers(1, &u.pbo)
+				r.deleteTexture(u.tex.id)
+				err := fmt.Errorf("texture upload %q: staging buffer lost before the transfer", u.label)
+				u.err.Store(&err)
+				log.Printf("gl: %v", err)
+				continue
+			}
+			r.bindTexture(0, u.tex.id)
+			gl.PixelStorei(gl.UNPACK_ALIGNMENT, 1)
+			gl.TexSubImage2D(gl.TEXTURE_2D, 0, 0, 0, int32(u.tex.w), int32(u.tex.h), gl.RGBA, gl.UNSIGNED_BYTE, nil) // from the PBO
+			gl.BindBuffer(gl.PIXEL_UNPACK_BUFFER, 0)
+			u.fence = gl.FenceSync(gl.SYNC_GPU_COMMANDS_COMPLETE, 0)
+		case u.fence != 0 && gl.ClientWaitSync(u.fence, 0, 0) != gl.TIMEOUT_EXPIRED:
+			gl.DeleteSync(u.fence)
+			gl.DeleteBuffers(1, &u.pbo)
+			u.ready.Store(true)
+			done = append(done, u)
+			continue
+		}
+		pending = append(pending, u)
+	}
+	clear(r.uploads[len(pending):])
+	r.uploads = pending
+
+	// after the list is settled: callbacks may start new uploads
+	for _, u := range done {
+		if u.onReady != nil {
+			u.onReady(u.tex)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package glbackend
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/hubastard/grove/engine/core"
+)
+
+// Uniform blocks are bound by name: each block name gets one binding point
+// for the lifetime of the renderer, and every pipeline declaring that block
+// is pointed at it when created. BindUniformBlock then swaps the buffer for
+// all of them at once.
+
+type uboGL struct {
+	id   uint32
+	size int
+}
+
+func (uboGL) IsUniformBuffer() {}
+
+func (r *RendererGL) CreateUniformBuffer(desc core.UniformBufferDesc) (core.UniformBuffer, error) {
+	defer r.checkError("CreateUniformBuffer")
+	if desc.Size <= 0 || len(desc.Data) > desc.Size {
+		return nil, fmt.Errorf("CreateUniformBuffer: size %d with %d bytes of data", desc.Size, len(desc.Data))
+	}
+	if desc.Size > r.caps.MaxUniformBlockSize {
+		return nil, fmt.Errorf("CreateUniformBuffer: size %d exceeds device limit %d", desc.Size, r.caps.MaxUniformBlockSize)
+	}
+
+	u := &uboGL{size: desc.Size}
+	gl.GenBuffers(1, &u.id)
+	gl.BindBuffer(gl.UNIFORM_BUFFER, u.id)
+	gl.BufferData(gl.UNIFORM_BUFFER, desc.Size, nil, gl.DYNAMIC_DRAW)
+	if len(desc.Data) > 0 {
+		gl.BufferSubData(gl.UNIFORM_BUFFER, 0, len(desc.Data), gl.Ptr(desc.Data))
+	}
+	gl.BindBuffer(gl.UNIFORM_BUFFER, 0)
+	r.label(gl.BUFFER, u.id, desc.Label)
+	return u, nil
+}
+
+// UpdateUniformBuffer writes data at offset. Draws already issued keep
+// seeing the previous contents.
+func (r *RendererGL) UpdateUniformBuffer(ub core.UniformBuffer, offset int, data []byte) error {
+	defer r.checkError("UpdateUniformBuffer")
+	u := ub.(*uboGL)
+	if offset < 0 || offset+len(data) > u.size {
+		return fmt.Errorf("UpdateUniformBuffer: %d bytes at %d overflow %d byte buffer", len(data), offset, u.size)
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	gl.BindBuffer(gl.UNIFORM_BUFFER, u.id)
+	gl.BufferSubData(gl.UNIFORM_BUFFER, offset, len(data), gl.Ptr(data))
+	gl.BindBuffer(gl.UNIFORM_BUFFER, 0)
+	return nil
+}
+
+func (r *RendererGL) BindUniformBlock(name string, ub core.UniformBuffer) {
+	defer r.checkError("BindUniformBlock")
+	binding, ok := r.blockBinding(name)
+	if !ok {
+		return
+	}
+	u := ub.(*uboGL)
+	gl.BindBufferBase(gl.UNIFORM_BUFFER, binding, u.id)
+	r.blockBufs[binding] = u
+}
+
+// blockBinding returns the binding point of block name, assigning the next
+// free one on first use.
+func (r *RendererGL) blockBinding(name string) (uint32, bool) {
+	if b, ok := r.blockBindings[name]; ok {
+		return b, true
+	}
+	if r.blockBindings == nil {
+		r.blockBindings = map[string]uint32{}
+		r.blockBufs = map[uint32]*uboGL{}
+	}
+	if len(r.blockBindings) >= r.caps.MaxUniformBindings {
+		log.Printf("gl: uniform block %q: out of binding points (%d)", name, r.caps.MaxUniformBindings)
+		return 0, false
+	}
+	b := uint32(len(r.blockBindings))
+	r.blockBindings[name] = b
+	return b, true
+}
+
+// bindBlocks points the blocks of a new pipeline at their binding points.
+func (r *RendererGL) bindBlocks(p *pipeGL) {
+	for i, blk := range p.info.Blocks {
+		if b, ok := r.blockBinding(blk.Name); ok {
+			gl.UniformBlockBinding(p.prog, uint32(i), b)
+		}
+	}
+}
+
+// checkBlocks warns about blocks drawn without a large enough buffer.
+func (r *RendererGL) checkBlocks(p *pipeGL) {
+	for _, blk := range p.info.Blocks {
+		u := r.blockBufs[r.blockBindings[blk.Name]]
+		switch {
+		case u == nil:
+			p.warnOnce("block "+blk.Name, "uniform block %q has no buffer bound", blk.Name)
+		case u.size < blk.Size:
+			p.warnOnce("block "+blk.Name, "uniform block %q needs %d bytes, bound buffer has %d", blk.Name, blk.Size, u.size)
+		}
+	}
+}
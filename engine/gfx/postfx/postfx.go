@@ -0,0 +1,100 @@
+package postfx
+
+import (
+	"github.com/hubastard/grove/engine/colors"
+	"github.com/hubastard/grove/engine/core"
+)
+
+// Pass renders a scene into an offscreen target and composites it onto the
+// window framebuffer, optionally multisampled and/or filtered with FXAA
+// (fullscreen.vert + fxaa.frag):
+//
+//	pass.Begin(w, h, clear)
+//	... draw the scene ...
+//	pass.End()
+type Pass struct {
+	Samples int  // MSAA samples of the offscreen target; applied by the next Begin
+	FXAA    bool // filter with FXAA when compositing, else a plain copy
+
+	r       core.Renderer
+	pipe    core.Pipeline
+	tri     core.Mesh
+	targets map[int]core.RenderTarget // by sample count, kept when toggling
+	target  core.RenderTarget         // of the current Begin, nil when skipped
+	w, h    int
+}
+
+var triLayout = core.VertexLayout{
+	Stride:     2 * 4,
+	Attributes: []core.VertexAttrib{{Location: 0, Size: 2, Type: core.AttribFloat32}},
+}
+
+func New(r core.Renderer, vert, frag core.ShaderSource) (*Pass, error) {
+	pipe, err := r.CreatePipeline(core.PipelineDesc{
+		VertexSource:   vert.Code,
+		FragmentSource: frag.Code,
+		VertexFiles:    vert.Files,
+		FragmentFiles:  frag.Files,
+		Layout:         triLayout,
+		Label:          "postfx",
+	})
+	if err != nil {
+		return nil, err
+	}
+	tri, err := r.CreateMesh(core.MeshDesc{
+		Vertices: []float32{-1, -1, 3, -1, -1, 3},
+		Layout:   triLayout,
+		Usage:    core.MeshStatic,
+		Label:    "postfx.triangle",
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Pass{r: r, pipe: pipe, tri: tri, targets: make(map[int]core.RenderTarget)}, nil
+}
+
+// Begin redirects drawing to a w x h offscreen target with Samples samples
+// and clears it. An empty size (minimized window) skips the pass: drawing
+// stays on the window framebuffer and End does nothing.
+func (p *Pass) Begin(w, h int, clear colors.Color) error {
+	if w < 1 || h < 1 {
+		p.target = nil
+		return nil
+	}
+	t, ok := p.targets[p.Samples]
+	if !ok {
+		var err error
+		t, err = p.r.CreateRenderTarget(core.RenderTargetDesc{Width: w, Height: h, Samples: p.Samples, Label: "postfx.scene"})
+		if err != nil {
+			return err
+		}
+		p.targets[p.Samples] = t
+	} else if err := p.r.ResizeRenderTarget(t, w, h); err != nil {
+		return err
+	}
+	p.target, p.w, p.h = t, w, h
+	p.r.SetRenderTarget(t)
+	p.r.Clear(clear[0], clear[1], clear[2], clear[3])
+	return nil
+}
+
+// End resolves the target and draws it over the window framebuffer.
+func (p *Pass) End() {
+	if p.target == nil {
+		return
+	}
+	p.r.ResolveRenderTarget(p.target)
+	p.r.SetRenderTarget(nil)
+	p.r.PushGPUScope("postfx")
+	p.r.Draw(core.DrawCmd{
+		Pipe:  p.pipe,
+		Mesh:  p.tri,
+		Count: 3,
+		Uniforms: map[string]any{
+			"uTexelSize": [2]float32{1 / float32(p.w), 1 / float32(p.h)},
+			"uFXAA":      p.FXAA,
+		},
+		Textures: []core.TextureBinding{{Uniform: "uScene", Texture: p.r.RenderTargetTexture(p.target)}},
+	})
+	p.r.PopGPUScope()
+}
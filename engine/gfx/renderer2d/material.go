@@ -0,0 +1,92 @@
+package renderer2d
+
+import (
+	"fmt"
+
+	"github.com/hubastard/grove/engine/core"
+)
+
+// -------- Materials --------
+
+// Units after the batch textures are kept for material textures, so a batch
+// samples at most MaxTextureUnits - maxMaterialTextures textures.
+const maxMaterialTextures = 4
+
+// Material draws with its own pipeline, uniforms and extra textures. The
+// pipeline reads the batch vertex layout and samples the batch textures
+// like renderer2d.frag (uTex[MAX_TEXTURES] indexed by vTexIndex); see
+// CreateMaterial.
+type Material struct {
+	Pipeline core.Pipeline
+	Uniforms map[string]any // sent with every batch drawn with the material
+
+	// Extra textures (at most 4), bound to the units after the batch
+	// textures; Uniform names the sampler, e.g. "uNoise".
+	Textures []core.TextureBinding
+}
+
+// CreateMaterial compiles frag against the renderer's vertex shader. frag
+// gets MAX_TEXTURES defined like the default fragment shader.
+func (rd *Renderer2D) CreateMaterial(frag core.ShaderSource, label string) (*Material, error) {
+	pipe, err := rd.r.CreatePipeline(core.PipelineDesc{
+		VertexSource:   rd.vert.Code,
+		FragmentSource: defineAfterVersion(frag.Code, "MAX_TEXTURES", len(rd.texArr)),
+		VertexFiles:    rd.vert.Files,
+		FragmentFiles:  frag.Files,
+		DepthTest:      false,
+		Blend:          true,
+		Layout:         quadVertexLayout,
+		Label:          label,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("renderer2d: material %q: %w", label, err)
+	}
+	return &Material{Pipeline: pipe, Uniforms: make(map[string]any)}, nil
+}
+
+// SetMaterial draws the following draws of this scene with m (nil => the
+// default pipeline) unless they pass their own (DrawMaterialQuad,
+// Sprite.Material). Batches break only when the material changes.
+// BeginScene resets it.
+func (rd *Renderer2D) SetMaterial(m *Material) {
+	checkMaterial(m)
+	rd.material = m
+}
+
+func checkMaterial(m *Material) {
+	if m != nil && len(m.Textures) > maxMaterialTextures {
+		panic(fmt.Sprintf("renderer2d: material has %d textures, the limit is %d", len(m.Textures), maxMaterialTextures))
+	}
+}
+
+// PushMaterial sets m until the matching PopMaterial.
+func (rd *Renderer2D) PushMaterial(m *Material) {
+	rd.materials = append(rd.materials, rd.material)
+	rd.SetMaterial(m)
+}
+
+// PopMaterial restores the material from before the last PushMaterial.
+func (rd *Renderer2D) PopMaterial() {
+	n := len(rd.materials)
+	if n == 0 {
+		panic("renderer2d: PopMaterial without PushMaterial")
+	}
+	m := rd.materials[n-1]
+	rd.materials = rd.materials[:n-1]
+	rd.SetMaterial(m)
+}
+
+// materialCmd points cmd at the material: its pipeline, uniforms and
+// textures after the batch texture units.
+func (rd *Renderer2D) materialCmd(cmd *core.DrawCmd, m *Material) {
+	cmd.Pipe = m.Pipeline
+	cmd.Uniforms = m.Uniforms
+	if len(m.Textures) == 0 {
+		return
+	}
+	for len(rd.textures) < len(rd.texArr) {
+		rd.textures = append(rd.textures, core.TextureBinding{})
+	}
+	rd.textures = append(rd.textures, m.Textures...)
+	cmd.Textures = rd.textures
+}
@@ -0,0 +1,135 @@
+package renderer2d
+
+import (
+	"log"
+
+	"github.com/hubastard/grove/engine/colors"
+)
+
+// -------- Nine-slice --------
+
+// Rect is an axis-aligned rectangle given by its top-left corner.
+type Rect struct{ X, Y, W, H float32 }
+
+// Insets are distances in from each edge of a rectangle.
+type Insets struct{ Left, Top, Right, Bottom float32 }
+
+type SliceMode int
+
+const (
+	SliceStretch SliceMode = iota // scale the slice to fill its cell
+	SliceTile                     // repeat the slice at its own size, cropping the last one
+)
+
+// NineSliceOptions configures DrawNineSliceWithOptions.
+type NineSliceOptions struct {
+	Edges  SliceMode
+	Center SliceMode
+	Scale  float32 // world units per texture pixel for borders and tiles (default: 1)
+	Layer  int     // added to the layer set by SetLayer (sorted modes only)
+	Depth  float32 // added to the depth set by SetDepth (sorted modes only)
+}
+
+// DrawNineSlice draws sub into r split by insets (in texture pixels): the
+// corners keep their size, the edges and center stretch. sub must know its
+// pixel size (FromPixels, FromGrid, FromTexture); without it, sub is
+// stretched over r as a plain quad and a warning is logged once.
+func (rd *Renderer2D) DrawNineSlice(r Rect, sub SubTexture2D, insets Insets, tint colors.Color) {
+	rd.DrawNineSliceWithOptions(r, sub, insets, tint, NineSliceOptions{})
+}
+
+// DrawNineSliceWithOptions is DrawNineSlice with tiling and scale. When r is
+// smaller than its borders, the borders shrink to fit.
+func (rd *Renderer2D) DrawNineSliceWithOptions(r Rect, sub SubTexture2D, insets Insets, tint colors.Color, opts NineSliceOptions) {
+	layer, depth := rd.layer+int32(opts.Layer), rd.depth+opts.Depth
+	packed := PackColor(tint)
+	if sub.W <= 0 || sub.H <= 0 {
+		if !rd.warnedSliceSize {
+			rd.warnedSliceSize = true
+			log.Printf("renderer2d: DrawNineSlice needs the pixel size of the subtexture (W, H); drawing it stretched")
+		}
+		rd.pushRect(sub, packed, layer, depth, r.X, r.Y, r.X+r.W, r.Y+r.H, sub.U0, sub.V0, sub.U1, sub.V1)
+		return
+	}
+	scale := opts.Scale
+	if scale <= 0 {
+		scale = 1
+	}
+
+	dl, dr := fitBorders(insets.Left*scale, insets.Right*scale, r.W)
+	dt, db := fitBorders(insets.Top*scale, insets.Bottom*scale, r.H)
+	xs := [4]float32{r.X, r.X + dl, r.X + r.W - dr, r.X + r.W}
+	ys := [4]float32{r.Y, r.Y + dt, r.Y + r.H - db, r.Y + r.H}
+
+	du, dv := (sub.U1-sub.U0)/sub.W, (sub.V1-sub.V0)/sub.H // per pixel
+	us := [4]float32{sub.U0, sub.U0 + insets.Left*du, sub.U1 - insets.Right*du, sub.U1}
+	vs := [4]float32{sub.V0, sub.V0 + insets.Top*dv, sub.V1 - insets.Bottom*dv, sub.V1}
+
+	// tile size of the middle column / row
+	tileW := (sub.W - insets.Left - insets.Right) * scale
+	tileH := (sub.H - insets.Top - insets.Bottom) * scale
+
+	for row := 0; row < 3; row++ {
+		for col := 0; col < 3; col++ {
+			if xs[col+1] <= xs[col] || ys[row+1] <= ys[row] {
+				continue
+			}
+			mode := opts.Edges
+			if row == 1 && col == 1 {
+				mode = opts.Center
+			}
+			var tw, th float32
+			if mode == SliceTile {
+				if col == 1 {
+					tw = tileW
+				}
+				if row == 1 {
+					th = tileH
+				}
+			}
+			rd.sliceCell(sub, packed, layer, depth, xs[col], ys[row], xs[col+1], ys[row+1], us[col], vs[row], us[col+1], vs[row+1], tw, th)
+		}
+	}
+}
+
+// fitBorders scales two border sizes down to fit in size.
+func fitBorders(a, b, size float32) (float32, float32) {
+	if a+b > size && a+b > 0 {
+		s := max(size, 0) / (a + b)
+		return a * s, b * s
+	}
+	return a, b
+}
+
+// sliceCell fills a cell with its UV rect, tiled every tw / th units when
+// they are > 0.
+func (rd *Renderer2D) sliceCell(sub SubTexture2D, color [4]uint8, layer int32, depth float32, x0, y0, x1, y1, u0, v0, u1, v1, tw, th float32) {
+	stepX, stepY := x1-x0, y1-y0
+	if tw > 0 {
+		stepX = tw
+	}
+	if th > 0 {
+		stepY = th
+	}
+	for y := y0; y < y1; y += stepY {
+		ye := min(y+stepY, y1)
+		ve := v0 + (v1-v0)*(ye-y)/stepY
+		for x := x0; x < x1; x += stepX {
+			xe := min(x+stepX, x1)
+			ue := u0 + (u1-u0)*(xe-x)/stepX
+			rd.pushRect(sub, color, layer, depth, x, y, xe, ye, u0, v0, ue, ve)
+		}
+	}
+}
+
+// pushRect draws an axis-aligned quad at layer and depth.
+func (rd *Renderer2D) pushRect(sub SubTexture2D, color [4]uint8, layer int32, depth float32, x0, y0, x1, y1, u0, v0, u1, v1 float32) {
+	verts := [vertsPerQuad]Vertex{ // TL, TR, BL, BR
+		{X: x0, Y: y0, Color: color, U: u0, V: v0},
+		{X: x1, Y: y0, Color: color, U: u1, V: v0},
+		{X: x0, Y: y1, Color: color, U: u0, V: v1},
+		{X: x1, Y: y1, Color: color, U: u1, V: v1},
+	}
+	rd.pushAt(layer, depth, sub.Texture, rd.material, verts[:], quadIndices[:])
+	rd.stats.QuadCount++
+}
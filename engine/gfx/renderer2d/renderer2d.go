@@ -1,28 +1,46 @@
 package renderer2d
 
 import (
-	"math"
+	"fmt"
 	"strconv"
+	"strings"
+	"unsafe"
 
 	"github.com/hubastard/grove/engine/colors"
 	"github.com/hubastard/grove/engine/core"
 )
 
-// Max textures per batch (common GL limit is 16)
-const maxTexSlots = 16
+// Upper bound on textures per batch; the device limit (Caps.MaxTextureUnits,
+// less the material units) usually applies first. Large sampler arrays get
+// slow to index.
+const maxTexSlots = 32
+
+// CameraBlock is the uniform block holding the scene's view-projection:
+//
+//	layout(std140) uniform Camera2D { mat4 uViewProj; };
+const CameraBlock = "Camera2D"
 
-// Vertex: pos2 + color4 + uv2 + texIndex1 => 9 floats
-const vStride = 9
 const vertsPerQuad = 4
 const indsPerQuad = 6
 
+// Vertex is the packed per-vertex layout shared with renderer2d.vert:
+// pos2 (f32) + color4 (unorm8) + uv2 (f32) + texIndex (u32) => 24 bytes.
+type Vertex struct {
+	X, Y     float32
+	Color    [4]uint8
+	U, V     float32
+	TexIndex uint32
+}
+
+const vertexSize = int(unsafe.Sizeof(Vertex{}))
+
 var quadVertexLayout = core.VertexLayout{
-	Stride: vStride * 4,
+	Stride: vertexSize,
 	Attributes: []core.VertexAttrib{
-		{Location: 0, Size: 2, Type: core.AttribFloat32, Offset: 0},     // pos
-		{Location: 1, Size: 4, Type: core.AttribFloat32, Offset: 2 * 4}, // color
-		{Location: 2, Size: 2, Type: core.AttribFloat32, Offset: 6 * 4}, // uv
-		{Location: 3, Size: 1, Type: core.AttribFloat32, Offset: 8 * 4}, // texIndex
+		{Location: 0, Size: 2, Type: core.AttribFloat32, Offset: 0},                     // pos
+		{Location: 1, Size: 4, Type: core.AttribUint8, Normalized: true, Offset: 2 * 4}, // color
+		{Location: 2, Size: 2, Type: core.AttribFloat32, Offset: 3 * 4},                 // uv
+		{Location: 3, Size: 1, Type: core.AttribUint32, Offset: 5 * 4},                  // texIndex
 	},
 }
 
@@ -30,47 +48,112 @@ var quadVertexLayout = core.VertexLayout{
 type Statistics struct {
 	DrawCalls    int
 	QuadCount    int
+	ShapeCount   int // lines, circles, polygons...
+	VertexCount  int
+	IndexCount   int
 	TextureCount int
 }
 
 // TotalVertexCount reports vertices submitted this frame.
-func (s Statistics) TotalVertexCount() int { return s.QuadCount * vertsPerQuad }
+func (s Statistics) TotalVertexCount() int { return s.VertexCount }
 
 // TotalIndexCount reports indices submitted this frame.
-func (s Statistics) TotalIndexCount() int { return s.QuadCount * indsPerQuad }
+func (s Statistics) TotalIndexCount() int { return s.IndexCount }
 
 type Renderer2D struct {
 	r      core.Renderer
 	pipe   core.Pipeline
-	white  core.Texture // 1x1 white (slot 0)
-	texArr [maxTexSlots]core.Texture
+	vert   core.ShaderSource // kept for CreateMaterial
+	white  core.Texture      // 1x1 white (slot 0)
+	texArr []core.Texture
 	texCnt int
 
-	verts     []float32
-	inds      []uint32
-	quadCount int
-	maxQuads  int
+	verts    []Vertex
+	inds     []uint32
+	maxVerts int // batch capacity
+	maxInds  int
 
 	mesh     core.Mesh
-	samplers map[string]core.Texture
-	uniforms map[string]any
-	texNames [maxTexSlots]string
+	textures []core.TextureBinding // per-flush bindings, slot i = unit i
+	texNames []string
+	sampler  core.Sampler // overrides texture filtering; nil => texture's own
+
+	material  *Material   // for the following draws, nil => pipe
+	materials []*Material // PushMaterial stack
+	batchMat  *Material   // of the batch being filled
+	camera    core.UniformBuffer
+	std140    core.Std140
 
-	_vp           [16]float32
 	stats         Statistics
 	extraUniforms map[string]any
+	uniformsDirty bool // extraUniforms changed since the last draw
+
+	sortMode SortMode
+	layer    int32   // applied to following draws (SetLayer)
+	depth    float32 // applied to following draws (SetDepth)
+	queue    sortQueue
+
+	// shape scratch (shapes.go)
+	tolerance  float32
+	shapeColor [4]uint8
+	shapePts   [][2]float32
+	shapeVerts []Vertex
+	shapeInds  []uint32
+	polyIdx    []int
+
+	// pushSplit scratch
+	splitVerts []Vertex
+	splitInds  []uint32
+	splitSrc   []uint32 // source index of each split vertex
+	splitMap   []int32  // source index -> split index, -1 if not copied yet
+
+	warnedSliceSize bool // DrawNineSlice got a subtexture without W, H
 }
 
-// New creates renderer and compiles the shader pipeline.
+// New creates renderer and compiles the shader pipeline. The fragment shader
+// gets MAX_TEXTURES defined to the number of texture slots the device allows;
+// under Caps.LinearColor the vertex shader gets LINEAR_COLOR, and must then
+// convert the sRGB vertex colors to linear.
 func New(r core.Renderer, vertSrc, fragSrc string, maxQuads int) (*Renderer2D, error) {
+	return NewFromSources(r, core.ShaderSource{Code: vertSrc}, core.ShaderSource{Code: fragSrc}, maxQuads)
+}
+
+// NewFromSources is New for preprocessed shaders (assets.LoadShaderSource),
+// so compile errors name the original files.
+func NewFromSources(r core.Renderer, vert, frag core.ShaderSource, maxQuads int) (*Renderer2D, error) {
+	return NewWithOptions(r, Options{Vertex: vert, Fragment: frag, MaxQuads: maxQuads, Usage: core.MeshStream})
+}
+
+// Options configures NewWithOptions.
+type Options struct {
+	Vertex, Fragment core.ShaderSource
+	MaxQuads         int            // quads per batch (default: 10000)
+	Usage            core.MeshUsage // how the batch mesh is updated; New uses core.MeshStream
+}
+
+func NewWithOptions(r core.Renderer, opts Options) (*Renderer2D, error) {
+	vert, frag, maxQuads := opts.Vertex, opts.Fragment, opts.MaxQuads
 	if maxQuads <= 0 {
 		maxQuads = 10000
 	}
+	units := r.Caps().MaxTextureUnits
+	slots := min(units-maxMaterialTextures, maxTexSlots)
+	if slots < 1 {
+		return nil, fmt.Errorf("renderer2d: device reports %d texture units", units)
+	}
+
+	if r.Caps().LinearColor {
+		vert.Code = defineAfterVersion(vert.Code, "LINEAR_COLOR", 1)
+	}
 	pipe, err := r.CreatePipeline(core.PipelineDesc{
-		VertexSource:   vertSrc,
-		FragmentSource: fragSrc,
+		VertexSource:   vert.Code,
+		FragmentSource: defineAfterVersion(frag.Code, "MAX_TEXTURES", slots),
+		VertexFiles:    vert.Files,
+		FragmentFiles:  frag.Files,
 		DepthTest:      false,
 		Blend:          true,
+		Layout:         quadVertexLayout,
+		Label:          "Renderer2D",
 	})
 	if err != nil {
 		return nil, err
@@ -84,56 +167,100 @@ func New(r core.Renderer, vertSrc, fragSrc string, maxQuads int) (*Renderer2D, e
 		Pixels:    whitePix,
 		MinFilter: "nearest", MagFilter: "nearest",
 		WrapU: "clamp", WrapV: "clamp",
+		Label: "Renderer2D.white",
 	})
 	if err != nil {
 		return nil, err
 	}
 
 	rd := &Renderer2D{
-		r: r, pipe: pipe, white: white, maxQuads: maxQuads,
-		verts: make([]float32, 0, maxQuads*vertsPerQuad*vStride),
-		inds:  make([]uint32, 0, maxQuads*indsPerQuad),
+		r: r, pipe: pipe, vert: vert, white: white,
+		maxVerts: maxQuads * vertsPerQuad, maxInds: maxQuads * indsPerQuad,
+		tolerance: 0.25,
+		verts:     make([]Vertex, 0, maxQuads*vertsPerQuad),
+		inds:      make([]uint32, 0, maxQuads*indsPerQuad),
+		texArr:    make([]core.Texture, slots),
 	}
 
 	// Create a reusable mesh large enough for the biggest batch.
-	initialVerts := make([]float32, maxQuads*vertsPerQuad*vStride)
+	initialVerts := make([]byte, maxQuads*vertsPerQuad*vertexSize)
 	initialInds := make([]uint32, maxQuads*indsPerQuad)
 	mesh, err := r.CreateMesh(core.MeshDesc{
-		Vertices: initialVerts,
-		Indices:  initialInds,
-		Layout:   quadVertexLayout,
+		VertexData: initialVerts,
+		Indices:    initialInds,
+		Layout:     quadVertexLayout,
+		Usage:      opts.Usage,
+		Label:      "Renderer2D.batch",
 	})
 	if err != nil {
 		return nil, err
 	}
 	rd.mesh = mesh
 
-	rd.samplers = make(map[string]core.Texture, maxTexSlots)
-	rd.uniforms = make(map[string]any, 4)
-	for i := 0; i < maxTexSlots; i++ {
+	rd.camera, err = r.CreateUniformBuffer(core.UniformBufferDesc{Size: 64, Label: "Renderer2D.camera"})
+	if err != nil {
+		return nil, err
+	}
+
+	rd.textures = make([]core.TextureBinding, 0, slots+maxMaterialTextures)
+	rd.texNames = make([]string, slots)
+	for i := range rd.texNames {
 		rd.texNames[i] = "uTex[" + strconv.Itoa(i) + "]"
 	}
 
 	return rd, nil
 }
 
+// BeginScene uploads vp to the CameraBlock uniform buffer. Draws already
+// flushed by a previous scene keep their camera.
 func (rd *Renderer2D) BeginScene(vp [16]float32) {
-	rd._vp = vp
+	rd.std140.Reset()
+	rd.std140.Mat4(vp)
+	if err := rd.r.UpdateUniformBuffer(rd.camera, 0, rd.std140.End()); err != nil {
+		panic(err)
+	}
+	rd.r.BindUniformBlock(CameraBlock, rd.camera)
+
+	rd.sampler = nil
+	rd.material, rd.materials = nil, rd.materials[:0]
+	rd.layer, rd.depth = 0, 0
 	rd.stats = Statistics{}
+	rd.queue.reset()
 	rd.resetBatch()
 }
 
-func (rd *Renderer2D) EndScene() { rd.flush() }
+// EndScene draws what is left of the scene; in a sorted mode, that is all of it.
+func (rd *Renderer2D) EndScene() {
+	rd.drawQueue()
+	rd.flush()
+}
+
+// SetSampler draws the following quads of this scene with s instead of each
+// texture's own filtering and wrap (nil restores them). BeginScene resets it.
+func (rd *Renderer2D) SetSampler(s core.Sampler) {
+	if s == rd.sampler {
+		return
+	}
+	if rd.sortMode == SortNone {
+		rd.flush()
+	}
+	rd.sampler = s
+}
+
+// MaxTextureSlots returns how many textures a single batch can sample.
+func (rd *Renderer2D) MaxTextureSlots() int { return len(rd.texArr) }
 
 // Stats returns the current frame statistics snapshot.
 func (rd *Renderer2D) Stats() Statistics { return rd.stats }
 
-// SetUniform queues an additional uniform to be sent on every draw call.
-// The uniform persists until overwritten; call with nil to remove.
+// SetUniform sets an additional uniform on the 2D pipeline. It is sent with
+// the next draw and persists until overwritten; call with nil to stop
+// sending it (the program keeps the last value).
 func (rd *Renderer2D) SetUniform(name string, value any) {
 	if rd.extraUniforms == nil {
 		rd.extraUniforms = make(map[string]any)
 	}
+	rd.uniformsDirty = true
 	if value == nil {
 		delete(rd.extraUniforms, name)
 		return
@@ -143,115 +270,173 @@ func (rd *Renderer2D) SetUniform(name string, value any) {
 
 // Draw solid color quad (uses white texture in slot 0)
 func (rd *Renderer2D) DrawQuad(x, y, w, h float32, color colors.Color, rotationRad float32) {
-	rd.ensureQuadCapacity()
-	rd.drawQuadInternal(x, y, w, h, color, rotationRad, rd.texSlot(rd.white), 0, 0, 1, 1)
+	rd.DrawSprite(Sprite{X: x, Y: y, W: w, H: h, Pivot: PivotCenter, Rotation: rotationRad, Tint: color})
 }
 
 // Draw textured quad with UVs (tint color)
 func (rd *Renderer2D) DrawTexturedQuad(x, y, w, h float32, tex core.Texture, tint colors.Color, rotationRad float32) {
-	rd.ensureQuadCapacity()
-	slot := rd.texSlot(tex)
-	rd.drawQuadInternal(x, y, w, h, tint, rotationRad, slot, 0, 0, 1, 1)
+	rd.DrawSprite(Sprite{X: x, Y: y, W: w, H: h, Pivot: PivotCenter, Rotation: rotationRad, Tint: tint,
+		Sub: SubTexture2D{Texture: tex, U1: 1, V1: 1}})
 }
 
 // Draw textured sub-rect (UV rect: u0,v0 -> u1,v1)
 func (rd *Renderer2D) DrawTexturedQuadUV(x, y, w, h float32, tex core.Texture, tint colors.Color, rotationRad float32, u0, v0, u1, v1 float32) {
-	rd.ensureQuadCapacity()
-	slot := rd.texSlot(tex)
-	rd.drawQuadInternal(x, y, w, h, tint, rotationRad, slot, u0, v0, u1, v1)
+	rd.DrawSprite(Sprite{X: x, Y: y, W: w, H: h, Pivot: PivotCenter, Rotation: rotationRad, Tint: tint,
+		Sub: SubTexture2D{Texture: tex, U0: u0, V0: v0, U1: u1, V1: v1}})
 }
 
 // DrawSubTexQuad draws a quad using a SubTexture2D (tint + rotation optional).
 func (rd *Renderer2D) DrawSubTexQuad(x, y, w, h float32, sub SubTexture2D, tint colors.Color, rotationRad float32) {
-	rd.ensureQuadCapacity()
-	slot := rd.texSlot(sub.Texture)
-	rd.drawQuadInternal(x, y, w, h, tint, rotationRad, slot, sub.U0, sub.V0, sub.U1, sub.V1)
+	rd.DrawSprite(Sprite{X: x, Y: y, W: w, H: h, Pivot: PivotCenter, Rotation: rotationRad, Tint: tint, Sub: sub})
+}
+
+// DrawMaterialQuad draws a quad like DrawSubTexQuad with mat instead of the
+// current material (nil => the default pipeline).
+func (rd *Renderer2D) DrawMaterialQuad(x, y, w, h float32, sub SubTexture2D, tint colors.Color, rotationRad float32, mat *Material) {
+	rd.DrawSprite(Sprite{X: x, Y: y, W: w, H: h, Pivot: PivotCenter, Rotation: rotationRad, Tint: tint, Sub: sub, Material: mat})
+}
+
+// DrawTriangles draws prebuilt geometry sampling tex (nil => white): inds
+// index verts, whose TexIndex is ignored and whose colors are sRGB (see
+// PackColor). Geometry larger than a batch (Options.MaxQuads * 4 vertices)
+// is drawn in several.
+func (rd *Renderer2D) DrawTriangles(tex core.Texture, verts []Vertex, inds []uint32) {
+	if len(inds) == 0 {
+		return
+	}
+	if tex == nil {
+		tex = rd.white
+	}
+	if len(verts) > rd.maxVerts || len(inds) > rd.maxInds {
+		rd.pushSplit(tex, rd.material, verts, inds)
+		return
+	}
+	rd.push(tex, rd.material, verts, inds)
 }
 
 // --- internals ---
 
-func (rd *Renderer2D) texSlot(t core.Texture) float32 {
+func (rd *Renderer2D) texSlot(t core.Texture) uint32 {
 	// already in array?
 	for i := 0; i < rd.texCnt; i++ {
 		if rd.texArr[i] == t {
-			return float32(i)
+			return uint32(i)
 		}
 	}
 	// need a new slot
-	if rd.texCnt >= maxTexSlots {
+	if rd.texCnt >= len(rd.texArr) {
 		// flush and reset texture bindings
 		rd.flush()
 	}
 	rd.texArr[rd.texCnt] = t
 	rd.texCnt++
 	rd.stats.TextureCount = rd.texCnt
-	return float32(rd.texCnt - 1)
+	return uint32(rd.texCnt - 1)
 }
 
-func (rd *Renderer2D) drawQuadInternal(x, y, w, h float32, color colors.Color, rotationRad float32, texIndex float32, u0, v0, u1, v1 float32) {
-	halfW := w * 0.5
-	halfH := h * 0.5
-
-	// corners (TL, TR, BL, BR) with UVs. Positive Y goes down so top is -halfH.
-	corners := [4][4]float32{
-		{-halfW, -halfH, u0, v0},
-		{halfW, -halfH, u1, v0},
-		{-halfW, halfH, u0, v1},
-		{halfW, halfH, u1, v1},
-	}
-	c, s := float32(math.Cos(float64(rotationRad))), float32(math.Sin(float64(rotationRad)))
-
-	startVertex := uint32(len(rd.verts) / vStride)
-
-	for _, p := range corners {
-		rx := p[0]*c - p[1]*s + x
-		ry := p[0]*s + p[1]*c + y
-		u, v := p[2], p[3]
-		rd.verts = append(rd.verts,
-			rx, ry,
-			color[0], color[1], color[2], color[3],
-			u, v,
-			texIndex,
-		)
-	}
-	rd.inds = append(rd.inds,
-		startVertex+0, startVertex+2, startVertex+1,
-		startVertex+1, startVertex+2, startVertex+3,
-	)
-	rd.quadCount++
-	rd.stats.QuadCount++
+// quad corner order: TL, TR, BL, BR
+var quadIndices = [indsPerQuad]uint32{0, 2, 1, 1, 2, 3}
+
+// push draws triangles of tex with mat (nil => default pipeline) at the
+// current layer and depth; indices are relative to verts.
+func (rd *Renderer2D) push(tex core.Texture, mat *Material, verts []Vertex, inds []uint32) {
+	rd.pushAt(rd.layer, rd.depth, tex, mat, verts, inds)
 }
 
-func (rd *Renderer2D) flush() {
-	if rd.quadCount == 0 {
+// pushAt is push at an explicit layer and depth. In a sorted mode the draw is
+// queued until EndScene.
+func (rd *Renderer2D) pushAt(layer int32, depth float32, tex core.Texture, mat *Material, verts []Vertex, inds []uint32) {
+	rd.stats.VertexCount += len(verts)
+	rd.stats.IndexCount += len(inds)
+	if rd.sortMode != SortNone {
+		rd.queue.add(rd, layer, depth, tex, mat, verts, inds)
 		return
 	}
+	rd.emit(tex, mat, verts, inds)
+}
 
-	if err := rd.r.UpdateMesh(rd.mesh, rd.verts, rd.inds); err != nil {
-		panic(err)
+// pushSplit pushes triangles too large for one batch in batch-sized pieces,
+// in order, copying the vertices each piece uses.
+func (rd *Renderer2D) pushSplit(tex core.Texture, mat *Material, verts []Vertex, inds []uint32) {
+	remap := rd.splitMap[:0]
+	for range verts {
+		remap = append(remap, -1)
+	}
+	pv, pi, src := rd.splitVerts[:0], rd.splitInds[:0], rd.splitSrc[:0]
+	flush := func() {
+		rd.push(tex, mat, pv, pi)
+		for _, s := range src {
+			remap[s] = -1
+		}
+		pv, pi, src = pv[:0], pi[:0], src[:0]
+	}
+	for t := 0; t+3 <= len(inds); t += 3 {
+		if len(pv)+3 > rd.maxVerts || len(pi)+3 > rd.maxInds {
+			flush()
+		}
+		for _, s := range inds[t : t+3] {
+			if remap[s] < 0 {
+				remap[s] = int32(len(pv))
+				pv = append(pv, verts[s])
+				src = append(src, s)
+			}
+			pi = append(pi, uint32(remap[s]))
+		}
+	}
+	if len(pi) > 0 {
+		flush()
 	}
+	rd.splitVerts, rd.splitInds, rd.splitSrc, rd.splitMap = pv, pi, src, remap
+}
 
-	for k := range rd.samplers {
-		delete(rd.samplers, k)
+// emit appends triangles to the batch, flushing first when it is full or
+// drawn with another material.
+func (rd *Renderer2D) emit(tex core.Texture, mat *Material, verts []Vertex, inds []uint32) {
+	if mat != rd.batchMat {
+		rd.flush()
+		rd.batchMat = mat
 	}
-	for i := 0; i < rd.texCnt; i++ {
-		rd.samplers[rd.texNames[i]] = rd.texArr[i]
+	if len(rd.verts)+len(verts) > rd.maxVerts || len(rd.inds)+len(inds) > rd.maxInds {
+		rd.flush()
 	}
+	slot := rd.texSlot(tex)
 
-	for k := range rd.uniforms {
-		delete(rd.uniforms, k)
+	base := uint32(len(rd.verts))
+	for _, v := range verts {
+		v.TexIndex = slot
+		rd.verts = append(rd.verts, v)
 	}
-	rd.uniforms["uVP"] = rd._vp
-	for k, v := range rd.extraUniforms {
-		rd.uniforms[k] = v
+	for _, i := range inds {
+		rd.inds = append(rd.inds, base+i)
 	}
+}
 
-	rd.r.Draw(core.DrawCmd{
-		Pipe:     rd.pipe,
-		Mesh:     rd.mesh,
-		Uniforms: rd.uniforms,
-		Samplers: rd.samplers,
-	})
+func (rd *Renderer2D) flush() {
+	if len(rd.inds) == 0 {
+		return
+	}
+
+	rd.r.PushGPUScope("Renderer2D.flush")
+	defer rd.r.PopGPUScope()
+
+	if err := rd.r.UpdateMeshData(rd.mesh, vertexBytes(rd.verts), rd.inds); err != nil {
+		panic(err)
+	}
+
+	rd.textures = rd.textures[:0]
+	for i := 0; i < rd.texCnt; i++ {
+		rd.textures = append(rd.textures, core.TextureBinding{Uniform: rd.texNames[i], Texture: rd.texArr[i], Sampler: rd.sampler})
+	}
+
+	// Uniform values live in the program, so only send them after a change.
+	cmd := core.DrawCmd{Pipe: rd.pipe, Mesh: rd.mesh, Textures: rd.textures}
+	if rd.batchMat != nil {
+		rd.materialCmd(&cmd, rd.batchMat)
+	} else if rd.uniformsDirty {
+		cmd.Uniforms = rd.extraUniforms
+		rd.uniformsDirty = false
+	}
+	rd.r.Draw(cmd)
 	rd.stats.DrawCalls++
 
 	rd.resetBatch()
@@ -260,7 +445,6 @@ func (rd *Renderer2D) flush() {
 func (rd *Renderer2D) resetBatch() {
 	rd.verts = rd.verts[:0]
 	rd.inds = rd.inds[:0]
-	rd.quadCount = 0
 	for i := range rd.texArr {
 		rd.texArr[i] = nil
 	}
@@ -268,8 +452,42 @@ func (rd *Renderer2D) resetBatch() {
 	rd.texCnt = 1
 }
 
-func (rd *Renderer2D) ensureQuadCapacity() {
-	if rd.quadCount >= rd.maxQuads {
-		rd.flush()
+// defineAfterVersion inserts "#define name value" after the #version line of
+// src, followed by a #line directive so compiler messages keep file lines.
+func defineAfterVersion(src, name string, value int) string {
+	def := "#define " + name + " " + strconv.Itoa(value) + "\n"
+	if !strings.HasPrefix(src, "#version") {
+		return def + "#line 1\n" + src
+	}
+	eol := strings.IndexByte(src, '\n')
+	if eol < 0 {
+		return src + "\n" + def
+	}
+	return src[:eol+1] + def + "#line 2\n" + src[eol+1:]
+}
+
+// PackColor converts a float color to normalized RGBA8, as stored in Vertex.
+// Channels are clamped to [0,1]: vertex colors are 8-bit, so an HDR tint
+// above 1 (glow) draws as 1 and needs its own shader uniform instead.
+func PackColor(c colors.Color) [4]uint8 {
+	var out [4]uint8
+	for i, v := range c {
+		if v <= 0 {
+			continue
+		}
+		if v >= 1 {
+			out[i] = 255
+			continue
+		}
+		out[i] = uint8(v*255 + 0.5)
+	}
+	return out
+}
+
+// vertexBytes reinterprets the batch vertices as raw bytes without copying.
+func vertexBytes(v []Vertex) []byte {
+	if len(v) == 0 {
+		return nil
 	}
+	return unsafe.Slice((*byte)(unsafe.Pointer(&v[0])), len(v)*vertexSize)
 }
@@ -0,0 +1,102 @@
+package renderer2d
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"unsafe"
+
+	"github.com/hubastard/grove/engine/colors"
+	"github.com/hubastard/grove/engine/core"
+)
+
+// fakeObj stands in for every GPU object; name identifies it in results.
+type fakeObj struct{ name string }
+
+func (*fakeObj) IsTexture()       {}
+func (*fakeObj) IsMesh()          {}
+func (*fakeObj) IsPipeline()      {}
+func (*fakeObj) IsSampler()       {}
+func (*fakeObj) IsUniformBuffer() {}
+
+// batch is one Draw of the fake renderer, with the mesh data it drew.
+type batch struct {
+	verts    []Vertex
+	inds     []uint32
+	textures []core.TextureBinding
+}
+
+// tex returns the name of the texture vertex i samples.
+func (b batch) tex(i int) string {
+	return b.textures[b.verts[i].TexIndex].Texture.(*fakeObj).name
+}
+
+// fakeRenderer records what Renderer2D draws. Methods it doesn't use panic
+// through the nil embedded interface.
+type fakeRenderer struct {
+	core.Renderer
+	caps    core.Caps
+	verts   []Vertex // latest UpdateMeshData
+	inds    []uint32
+	batches []batch
+}
+
+func newFakeRenderer(textureUnits int) *fakeRenderer {
+	return &fakeRenderer{caps: core.Caps{MaxTextureUnits: textureUnits}}
+}
+
+func (r *fakeRenderer) Caps() core.Caps { return r.caps }
+func (r *fakeRenderer) CreatePipeline(desc core.PipelineDesc) (core.Pipeline, error) {
+	return &fakeObj{desc.Label}, nil
+}
+func (r *fakeRenderer) CreateTexture(desc core.TextureDesc) (core.Texture, error) {
+	return &fakeObj{desc.Label}, nil
+}
+func (r *fakeRenderer) CreateMesh(desc core.MeshDesc) (core.Mesh, error) {
+	return &fakeObj{desc.Label}, nil
+}
+func (r *fakeRenderer) CreateUniformBuffer(desc core.UniformBufferDesc) (core.UniformBuffer, error) {
+	return &fakeObj{desc.Label}, nil
+}
+func (r *fakeRenderer) UpdateUniformBuffer(core.UniformBuffer, int, []byte) error { return nil }
+func (r *fakeRenderer) BindUniformBlock(string, core.UniformBuffer)               {}
+func (r *fakeRenderer) PushGPUScope(string)                                       {}
+func (r *fakeRenderer) PopGPUScope()                                              {}
+
+func (r *fakeRenderer) UpdateMeshData(_ core.Mesh, vertices []byte, indices []uint32) error {
+	r.verts = slices.Clone(unsafe.Slice((*Vertex)(unsafe.Pointer(unsafe.SliceData(vertices))), len(vertices)/vertexSize))
+	r.inds = slices.Clone(indices)
+	return nil
+}
+
+func (r *fakeRenderer) Draw(cmd core.DrawCmd) {
+	r.batches = append(r.batches, batch{r.verts, r.inds, slices.Clone(cmd.Textures)})
+}
+
+// quads lists the drawn quads in order as "texture@x", x being the left edge.
+func (r *fakeRenderer) quads() []string {
+	var out []string
+	for _, b := range r.batches {
+		for i := 0; i < len(b.verts); i += vertsPerQuad {
+			out = append(out, fmt.Sprintf("%s@%g", b.tex(i), b.verts[i].X))
+		}
+	}
+	return out
+}
+
+func newTestRenderer(t *testing.T, textureUnits int) (*Renderer2D, *fakeRenderer) {
+	t.Helper()
+	r := newFakeRenderer(textureUnits)
+	rd, err := New(r, "#version 330 core\n", "#version 330 core\n", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rd, r
+}
+
+var testTextures = map[string]core.Texture{"A": &fakeObj{"A"}, "B": &fakeObj{"B"}, "C": &fakeObj{"C"}}
+
+// quad draws a 2x2 quad of texture name with its left edge at x.
+func quad(rd *Renderer2D, name string, x float32) {
+	rd.DrawTexturedQuad(x+1, 1, 2, 2, testTextures[name], colors.White, 0)
+}
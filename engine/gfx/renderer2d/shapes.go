@@ -0,0 +1,430 @@
+package renderer2d
+
+import (
+	"math"
+
+	"github.com/hubastard/grove/engine/colors"
+)
+
+// -------- Vector shapes --------
+
+// Shapes are triangulated on the CPU into the same batch as sprites (white
+// texture); shapes larger than a batch are drawn in several. Curves are
+// split into segments so that no point strays more than the curve tolerance
+// from the true curve (see SetCurveTolerance).
+
+type LineCap int
+
+const (
+	CapButt   LineCap = iota // ends at the endpoint
+	CapSquare                // extends half the width past the endpoint
+	CapRound                 // half circle around the endpoint
+)
+
+type LineJoin int
+
+const (
+	JoinMiter LineJoin = iota // sharp corner, bevelled past MiterLimit
+	JoinBevel                 // corner cut flat
+	JoinRound                 // arc around the corner
+)
+
+// LineStyle describes how DrawPolyline strokes a path.
+type LineStyle struct {
+	Width      float32
+	Cap        LineCap
+	Join       LineJoin
+	MiterLimit float32 // max miter length in widths before falling back to bevel (default: 4)
+}
+
+const maxCurveSegments = 512
+
+// SetCurveTolerance sets the max distance, in world units, between a curve
+// and its segments (default: 0.25). Lower it when zooming in on shapes.
+func (rd *Renderer2D) SetCurveTolerance(t float32) {
+	if t > 0 {
+		rd.tolerance = t
+	}
+}
+
+// DrawLine draws a segment of the given width with butt caps.
+func (rd *Renderer2D) DrawLine(x0, y0, x1, y1, width float32, color colors.Color) {
+	rd.DrawPolyline([][2]float32{{x0, y0}, {x1, y1}}, false, LineStyle{Width: width}, color)
+}
+
+// DrawPolyline strokes the path through pts, back to the first point when
+// closed (caps are then unused).
+func (rd *Renderer2D) DrawPolyline(pts [][2]float32, closed bool, style LineStyle, color colors.Color) {
+	hw := style.Width * 0.5
+	limit := style.MiterLimit
+	if limit <= 0 {
+		limit = 4
+	}
+
+	// drop repeated points, they have no direction (compacting in place is
+	// fine when pts is the shapePts scratch itself)
+	path := rd.shapePts[:0]
+	for _, p := range pts {
+		if len(path) == 0 || p != path[len(path)-1] {
+			path = append(path, p)
+		}
+	}
+	if closed && len(path) > 1 && path[0] == path[len(path)-1] {
+		path = path[:len(path)-1]
+	}
+	rd.shapePts = path
+	n := len(path)
+	if n < 2 || hw <= 0 {
+		return
+	}
+
+	rd.beginShape(color)
+	segs := n - 1
+	if closed {
+		segs = n
+	}
+	for i := 0; i < segs; i++ {
+		a, b := path[i], path[(i+1)%n]
+		dx, dy := unit(b[0]-a[0], b[1]-a[1])
+		nx, ny := -dy*hw, dx*hw
+
+		// caps extend the first and last segment
+		if !closed && style.Cap == CapSquare {
+			if i == 0 {
+				a[0], a[1] = a[0]-dx*hw, a[1]-dy*hw
+			}
+			if i == segs-1 {
+				b[0], b[1] = b[0]+dx*hw, b[1]+dy*hw
+			}
+		}
+		rd.shapeQuad(a[0]+nx, a[1]+ny, b[0]+nx, b[1]+ny, b[0]-nx, b[1]-ny, a[0]-nx, a[1]-ny)
+	}
+
+	// joins fill the wedge on the outer side of each corner
+	first, last := 1, n-1
+	if closed {
+		first, last = 0, n
+	}
+	for i := first; i < last; i++ {
+		p, prev, next := path[i], path[(i+n-1)%n], path[(i+1)%n]
+		d0x, d0y := unit(p[0]-prev[0], p[1]-prev[1])
+		d1x, d1y := unit(next[0]-p[0], next[1]-p[1])
+		cross := d0x*d1y - d0y*d1x
+		if cross == 0 {
+			continue // straight on (or a full reversal: nothing sensible to fill)
+		}
+		side := float32(-1)
+		if cross < 0 {
+			side = 1
+		}
+		// outer offsets of the incoming and outgoing segment
+		o0x, o0y := -d0y*hw*side, d0x*hw*side
+		o1x, o1y := -d1y*hw*side, d1x*hw*side
+
+		switch style.Join {
+		case JoinRound:
+			a0 := atan2(o0y, o0x)
+			sweep := wrapAngle(atan2(o1y, o1x) - a0)
+			rd.shapeFan(p[0], p[1], hw, hw, 0, a0, sweep)
+		case JoinMiter:
+			mx, my := unit(o0x+o1x, o0y+o1y)
+			cos := mx*o0x/hw + my*o0y/hw // cos of half the turn
+			if cos > 0 && 1/cos <= limit {
+				ml := hw / cos
+				v := rd.shapeVertex(p[0], p[1])
+				rd.shapeVertex(p[0]+o0x, p[1]+o0y)
+				rd.shapeVertex(p[0]+mx*ml, p[1]+my*ml)
+				rd.shapeVertex(p[0]+o1x, p[1]+o1y)
+				rd.shapeInds = append(rd.shapeInds, v, v+1, v+2, v, v+2, v+3)
+				continue
+			}
+			fallthrough
+		case JoinBevel:
+			v := rd.shapeVertex(p[0], p[1])
+			rd.shapeVertex(p[0]+o0x, p[1]+o0y)
+			rd.shapeVertex(p[0]+o1x, p[1]+o1y)
+			rd.shapeInds = append(rd.shapeInds, v, v+1, v+2)
+		}
+	}
+
+	if !closed && style.Cap == CapRound {
+		a, b := path[0], path[1]
+		rd.shapeFan(a[0], a[1], hw, hw, 0, atan2(a[1]-b[1], a[0]-b[0])-math.Pi/2, math.Pi)
+		a, b = path[n-1], path[n-2]
+		rd.shapeFan(a[0], a[1], hw, hw, 0, atan2(a[1]-b[1], a[0]-b[0])-math.Pi/2, math.Pi)
+	}
+	rd.endShape()
+}
+
+// DrawCircle fills a circle.
+func (rd *Renderer2D) DrawCircle(cx, cy, r float32, color colors.Color) {
+	rd.DrawEllipse(cx, cy, r, r, 0, color)
+}
+
+// DrawCircleOutline strokes a circle; the stroke is centered on r.
+func (rd *Renderer2D) DrawCircleOutline(cx, cy, r, width float32, color colors.Color) {
+	rd.DrawEllipseOutline(cx, cy, r, r, 0, width, color)
+}
+
+// DrawEllipse fills an ellipse with radii rx, ry rotated by rotationRad.
+func (rd *Renderer2D) DrawEllipse(cx, cy, rx, ry, rotationRad float32, color colors.Color) {
+	rd.beginShape(color)
+	rd.shapeFan(cx, cy, rx, ry, rotationRad, 0, 2*math.Pi)
+	rd.endShape()
+}
+
+// DrawEllipseOutline strokes an ellipse; the stroke is centered on the radii.
+func (rd *Renderer2D) DrawEllipseOutline(cx, cy, rx, ry, rotationRad, width float32, color colors.Color) {
+	rd.beginShape(color)
+	rd.shapeRing(cx, cy, rx, ry, rotationRad, 0, 2*math.Pi, width*0.5, true)
+	rd.endShape()
+}
+
+// DrawArc strokes the part of a circle from startRad to endRad (clockwise
+// on screen, as y points down) with butt ends.
+func (rd *Renderer2D) DrawArc(cx, cy, r, startRad, endRad, width float32, color colors.Color) {
+	rd.beginShape(color)
+	rd.shapeRing(cx, cy, r, r, 0, startRad, endRad-startRad, width*0.5, false)
+	rd.endShape()
+}
+
+// DrawPie fills the circle sector from startRad to endRad.
+func (rd *Renderer2D) DrawPie(cx, cy, r, startRad, endRad float32, color colors.Color) {
+	rd.beginShape(color)
+	rd.shapeFan(cx, cy, r, r, 0, startRad, endRad-startRad)
+	rd.endShape()
+}
+
+// DrawPolygon fills a simple polygon (convex or concave, either winding).
+func (rd *Renderer2D) DrawPolygon(pts [][2]float32, color colors.Color) {
+	if len(pts) < 3 {
+		return
+	}
+	rd.beginShape(color)
+	for _, p := range pts {
+		rd.shapeVertex(p[0], p[1])
+	}
+	rd.shapeInds = triangulate(pts, rd.shapeInds, &rd.polyIdx)
+	rd.endShape()
+}
+
+// DrawPolygonOutline strokes a closed polygon.
+func (rd *Renderer2D) DrawPolygonOutline(pts [][2]float32, style LineStyle, color colors.Color) {
+	rd.DrawPolyline(pts, true, style, color)
+}
+
+// DrawRoundedRect fills a w x h rectangle centered at (cx, cy), like
+// DrawQuad, with corners of the given radius.
+func (rd *Renderer2D) DrawRoundedRect(cx, cy, w, h, radius float32, color colors.Color) {
+	pts := rd.roundedRectPath(cx, cy, w, h, radius)
+	rd.beginShape(color)
+	for _, p := range pts {
+		rd.shapeVertex(p[0], p[1])
+	}
+	for i := 1; i+1 < len(pts); i++ { // convex: fan
+		rd.shapeInds = append(rd.shapeInds, 0, uint32(i), uint32(i+1))
+	}
+	rd.endShape()
+}
+
+// DrawRoundedRectOutline strokes a rounded rectangle; the stroke is centered
+// on its edge.
+func (rd *Renderer2D) DrawRoundedRectOutline(cx, cy, w, h, radius, width float32, color colors.Color) {
+	pts := rd.roundedRectPath(cx, cy, w, h, radius)
+	rd.DrawPolyline(pts, true, LineStyle{Width: width, Join: JoinMiter}, color)
+}
+
+// --- shape internals ---
+
+func (rd *Renderer2D) beginShape(color colors.Color) {
+	rd.shapeColor = PackColor(color)
+	rd.shapeVerts = rd.shapeVerts[:0]
+	rd.shapeInds = rd.shapeInds[:0]
+}
+
+func (rd *Renderer2D) endShape() {
+	if len(rd.shapeInds) == 0 {
+		return
+	}
+	if len(rd.shapeVerts) > rd.maxVerts || len(rd.shapeInds) > rd.maxInds {
+		rd.pushSplit(rd.white, rd.material, rd.shapeVerts, rd.shapeInds)
+	} else {
+		rd.push(rd.white, rd.material, rd.shapeVerts, rd.shapeInds)
+	}
+	rd.stats.ShapeCount++
+}
+
+// shapeVertex appends a vertex and returns its index within the shape.
+func (rd *Renderer2D) shapeVertex(x, y float32) uint32 {
+	rd.shapeVerts = append(rd.shapeVerts, Vertex{X: x, Y: y, Color: rd.shapeColor})
+	return uint32(len(rd.shapeVerts) - 1)
+}
+
+// shapeQuad appends the quad a-b-c-d (in order around it).
+func (rd *Renderer2D) shapeQuad(ax, ay, bx, by, cx, cy, dx, dy float32) {
+	v := rd.shapeVertex(ax, ay)
+	rd.shapeVertex(bx, by)
+	rd.shapeVertex(cx, cy)
+	rd.shapeVertex(dx, dy)
+	rd.shapeInds = append(rd.shapeInds, v, v+1, v+2, v, v+2, v+3)
+}
+
+// segments returns how many segments approximate sweep radians of a curve of
+// radius r within the curve tolerance.
+func (rd *Renderer2D) segments(r, sweep float32) int {
+	sweep = float32(math.Abs(float64(sweep)))
+	n := 1
+	if r > rd.tolerance {
+		step := 2 * math.Acos(1-float64(rd.tolerance/r))
+		n = int(math.Ceil(float64(sweep) / step))
+	}
+	if sweep >= 2*math.Pi-1e-4 {
+		n = max(n, 8)
+	}
+	return min(max(n, 1), maxCurveSegments)
+}
+
+// shapeFan appends a filled elliptic sector as a triangle fan.
+func (rd *Renderer2D) shapeFan(cx, cy, rx, ry, rot, start, sweep float32) {
+	n := rd.segments(max(rx, ry), sweep)
+	sr, cr := sincos(rot)
+	c := rd.shapeVertex(cx, cy)
+	for i := 0; i <= n; i++ {
+		s, co := sincos(start + sweep*float32(i)/float32(n))
+		x, y := co*rx, s*ry
+		rd.shapeVertex(cx+x*cr-y*sr, cy+x*sr+y*cr)
+		if i > 0 {
+			rd.shapeInds = append(rd.shapeInds, c, c+uint32(i), c+uint32(i)+1)
+		}
+	}
+}
+
+// shapeRing appends a band of half-width hw around an elliptic arc.
+func (rd *Renderer2D) shapeRing(cx, cy, rx, ry, rot, start, sweep, hw float32, closed bool) {
+	n := rd.segments(max(rx, ry)+hw, sweep)
+	sr, cr := sincos(rot)
+	first := uint32(len(rd.shapeVerts))
+	for i := 0; i <= n; i++ {
+		if closed && i == n {
+			break // the last pair is the first
+		}
+		s, co := sincos(start + sweep*float32(i)/float32(n))
+		for _, d := range [2]float32{-hw, hw} {
+			x, y := co*(rx+d), s*(ry+d)
+			rd.shapeVertex(cx+x*cr-y*sr, cy+x*sr+y*cr)
+		}
+	}
+	pairs := uint32(len(rd.shapeVerts)-int(first)) / 2
+	for i := uint32(0); i < uint32(n); i++ {
+		a, b := first+2*i, first+2*((i+1)%pairs)
+		rd.shapeInds = append(rd.shapeInds, a, a+1, b+1, a, b+1, b)
+	}
+}
+
+// roundedRectPath returns the outline of a rounded rectangle (clockwise on
+// screen) in the shape point scratch.
+func (rd *Renderer2D) roundedRectPath(cx, cy, w, h, radius float32) [][2]float32 {
+	hw, hh := w*0.5, h*0.5
+	radius = max(0, min(radius, hw, hh))
+	pts := rd.shapePts[:0]
+	corners := [4][3]float32{ // center of each corner arc and its start angle
+		{cx + hw - radius, cy - hh + radius, -math.Pi / 2}, // top right
+		{cx + hw - radius, cy + hh - radius, 0},            // bottom right
+		{cx - hw + radius, cy + hh - radius, math.Pi / 2},  // bottom left
+		{cx - hw + radius, cy - hh + radius, math.Pi},      // top left
+	}
+	n := rd.segments(radius, math.Pi/2)
+	if radius == 0 {
+		n = 0
+	}
+	for _, c := range corners {
+		for i := 0; i <= n; i++ {
+			s, co := sincos(c[2] + math.Pi/2*float32(i)/float32(max(n, 1)))
+			pts = append(pts, [2]float32{c[0] + co*radius, c[1] + s*radius})
+		}
+	}
+	rd.shapePts = pts
+	return pts
+}
+
+// triangulate appends triangles covering the simple polygon pts to inds
+// (ear clipping). idx is scratch space.
+func triangulate(pts [][2]float32, inds []uint32, idx *[]int) []uint32 {
+	n := len(pts)
+	var area float32
+	for i := range pts {
+		a, b := pts[i], pts[(i+1)%n]
+		area += a[0]*b[1] - b[0]*a[1]
+	}
+	orient := float32(1) // sign of convex corners
+	if area < 0 {
+		orient = -1
+	}
+
+	v := (*idx)[:0]
+	for i := 0; i < n; i++ {
+		v = append(v, i)
+	}
+	*idx = v
+
+	cross := func(a, b, c [2]float32) float32 {
+		return ((b[0]-a[0])*(c[1]-a[1]) - (b[1]-a[1])*(c[0]-a[0])) * orient
+	}
+	for len(v) > 3 {
+		ear := -1
+		for i := range v {
+			a, b, c := pts[v[(i+len(v)-1)%len(v)]], pts[v[i]], pts[v[(i+1)%len(v)]]
+			if cross(a, b, c) <= 0 {
+				continue // reflex or flat
+			}
+			inside := false
+			for _, j := range v {
+				p := pts[j]
+				if p == a || p == b || p == c {
+					continue
+				}
+				if cross(a, b, p) >= 0 && cross(b, c, p) >= 0 && cross(c, a, p) >= 0 {
+					inside = true
+					break
+				}
+			}
+			if !inside {
+				ear = i
+				break
+			}
+		}
+		if ear < 0 {
+			ear = 0 // self-intersecting or degenerate: clip anyway
+		}
+		prev, next := v[(ear+len(v)-1)%len(v)], v[(ear+1)%len(v)]
+		inds = append(inds, uint32(prev), uint32(v[ear]), uint32(next))
+		v = append(v[:ear], v[ear+1:]...)
+	}
+	return append(inds, uint32(v[0]), uint32(v[1]), uint32(v[2]))
+}
+
+func unit(x, y float32) (float32, float32) {
+	l := float32(math.Hypot(float64(x), float64(y)))
+	if l == 0 {
+		return 0, 0
+	}
+	return x / l, y / l
+}
+
+func sincos(a float32) (float32, float32) {
+	s, c := math.Sincos(float64(a))
+	return float32(s), float32(c)
+}
+
+func atan2(y, x float32) float32 { return float32(math.Atan2(float64(y), float64(x))) }
+
+// wrapAngle maps a to (-pi, pi].
+func wrapAngle(a float32) float32 {
+	for a > math.Pi {
+		a -= 2 * math.Pi
+	}
+	for a <= -math.Pi {
+		a += 2 * math.Pi
+	}
+	return a
+}
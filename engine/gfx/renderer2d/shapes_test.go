@@ -0,0 +1,151 @@
+package renderer2d
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/hubastard/grove/engine/colors"
+)
+
+type triangle [3][2]float32
+
+// triangles lists every drawn triangle in order, checking that each batch
+// fits the batch limits and only indexes its own vertices.
+func (r *fakeRenderer) triangles(t *testing.T, maxVerts, maxInds int) []triangle {
+	t.Helper()
+	var out []triangle
+	for n, b := range r.batches {
+		if len(b.verts) > maxVerts || len(b.inds) > maxInds {
+			t.Fatalf("batch %d: %d vertices, %d indices over the %d/%d limit", n, len(b.verts), len(b.inds), maxVerts, maxInds)
+		}
+		for i := 0; i+3 <= len(b.inds); i += 3 {
+			var tri triangle
+			for k, idx := range b.inds[i : i+3] {
+				if int(idx) >= len(b.verts) {
+					t.Fatalf("batch %d: index %d of %d vertices", n, idx, len(b.verts))
+				}
+				tri[k] = [2]float32{b.verts[idx].X, b.verts[idx].Y}
+			}
+			out = append(out, tri)
+		}
+	}
+	return out
+}
+
+func TestShapeSplit(t *testing.T) {
+	var star [][2]float32
+	for i := range 40 {
+		r := float32(100)
+		if i%2 == 1 {
+			r = 40
+		}
+		a := float64(i) * math.Pi / 20
+		star = append(star, [2]float32{r * float32(math.Cos(a)), r * float32(math.Sin(a))})
+	}
+	shapes := []struct {
+		name string
+		draw func(rd *Renderer2D)
+	}{
+		{"circle", func(rd *Renderer2D) { rd.DrawCircle(0, 0, 200, colors.White) }},
+		{"ring", func(rd *Renderer2D) { rd.DrawCircleOutline(0, 0, 200, 4, colors.White) }},
+		{"concave polygon", func(rd *Renderer2D) { rd.DrawPolygon(star, colors.White) }},
+		{"round polyline", func(rd *Renderer2D) {
+			rd.DrawPolyline(star, false, LineStyle{Width: 3, Cap: CapRound, Join: JoinRound}, colors.White)
+		}},
+	}
+	const maxQuads = 4 // 16 vertices, 24 indices per batch
+	for _, tc := range shapes {
+		t.Run(tc.name, func(t *testing.T) {
+			whole := newFakeRenderer(16)
+			rd, err := New(whole, "#version 330 core\n", "#version 330 core\n", 10000)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rd.BeginScene(identity)
+			tc.draw(rd)
+			rd.EndScene()
+			want := whole.triangles(t, math.MaxInt, math.MaxInt)
+			if len(whole.batches) != 1 || len(whole.batches[0].verts) <= maxQuads*vertsPerQuad {
+				t.Fatalf("shape too small to need splitting: %d vertices", len(whole.batches[0].verts))
+			}
+
+			split := newFakeRenderer(16)
+			rd, err = New(split, "#version 330 core\n", "#version 330 core\n", maxQuads)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rd.BeginScene(identity)
+			tc.draw(rd)
+			rd.EndScene()
+			got := split.triangles(t, maxQuads*vertsPerQuad, maxQuads*indsPerQuad)
+			if len(split.batches) < 2 {
+				t.Fatalf("drawn in %d batch", len(split.batches))
+			}
+			if !slices.Equal(got, want) {
+				t.Errorf("split into %d batches: %d triangles differ from the %d of one batch", len(split.batches), len(got), len(want))
+			}
+			if rd.Stats().ShapeCount != 1 {
+				t.Errorf("ShapeCount %d, want 1", rd.Stats().ShapeCount)
+			}
+		})
+	}
+}
+
+func TestDrawTrianglesSplit(t *testing.T) {
+	// a 6x6 grid sharing its vertices: 49 vertices, 216 indices
+	const n = 6
+	var verts []Vertex
+	for y := range n + 1 {
+		for x := range n + 1 {
+			verts = append(verts, Vertex{X: float32(x), Y: float32(y), Color: [4]uint8{uint8(x), uint8(y), 0, 255}})
+		}
+	}
+	var inds []uint32
+	var want []triangle
+	for y := range n {
+		for x := range n {
+			tl := uint32(y*(n+1) + x)
+			quad := []uint32{tl, tl + n + 1, tl + 1, tl + 1, tl + n + 1, tl + n + 2}
+			inds = append(inds, quad...)
+			for i := 0; i < len(quad); i += 3 {
+				var tri triangle
+				for k, idx := range quad[i : i+3] {
+					tri[k] = [2]float32{verts[idx].X, verts[idx].Y}
+				}
+				want = append(want, tri)
+			}
+		}
+	}
+
+	const maxQuads = 4 // 16 vertices, 24 indices per batch
+	r := newFakeRenderer(16)
+	rd, err := New(r, "#version 330 core\n", "#version 330 core\n", maxQuads)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rd.BeginScene(identity)
+	rd.DrawTriangles(testTextures["A"], verts, inds)
+	rd.EndScene()
+
+	got := r.triangles(t, maxQuads*vertsPerQuad, maxQuads*indsPerQuad)
+	if len(r.batches) < 2 {
+		t.Fatalf("drawn in %d batch", len(r.batches))
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("split into %d batches: %d triangles differ from the %d given", len(r.batches), len(got), len(want))
+	}
+	for n, b := range r.batches {
+		for i, v := range b.verts {
+			if b.tex(i) != "A" {
+				t.Fatalf("batch %d: vertex %d samples %s", n, i, b.tex(i))
+			}
+			if v.Color != [4]uint8{uint8(v.X), uint8(v.Y), 0, 255} {
+				t.Fatalf("batch %d: vertex at (%g, %g) has color %v", n, v.X, v.Y, v.Color)
+			}
+		}
+	}
+	if got := rd.Stats().IndexCount; got != len(inds) {
+		t.Errorf("IndexCount %d, want %d", got, len(inds))
+	}
+}
@@ -0,0 +1,160 @@
+package renderer2d
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/hubastard/grove/engine/core"
+)
+
+// -------- Layer / depth sorting --------
+
+// SortMode selects the order in which a scene's draws reach the GPU.
+type SortMode int
+
+const (
+	// SortNone draws in submission order; layer and depth are ignored.
+	SortNone SortMode = iota
+	// SortLayerDepth queues the scene and draws it by layer, then depth
+	// (lower first, so higher values end up on top).
+	SortLayerDepth
+	// SortLayerY is SortLayerDepth with the bottom edge of each draw's bounds
+	// taking the place of depth, for top-down scenes where what stands lower
+	// on screen (larger y) is in front. Depth breaks ties.
+	SortLayerY
+)
+
+// Within equal keys, draws are grouped by texture (and sampler and material)
+// to save flushes, but a draw never moves past one it overlaps, so blending
+// (translucency included) composes as submitted.
+
+// groupLookback bounds how far back a draw may move to join its texture.
+const groupLookback = 64
+
+// SetSortMode changes the order of the following draws. Draws already made
+// in this scene are drawn first.
+func (rd *Renderer2D) SetSortMode(m SortMode) {
+	if m == rd.sortMode {
+		return
+	}
+	rd.drawQueue()
+	rd.flush()
+	rd.sortMode = m
+}
+
+// SetLayer sets the layer of the following draws of this scene (sorted modes
+// only); Sprite.Layer and NineSliceOptions.Layer are added to it. BeginScene resets it to 0.
+func (rd *Renderer2D) SetLayer(layer int) { rd.layer = int32(layer) }
+
+// SetDepth sets the depth of the following draws within their layer (sorted
+// modes only); Sprite.Depth and NineSliceOptions.Depth are added to it.
+// BeginScene resets it to 0.
+func (rd *Renderer2D) SetDepth(depth float32) { rd.depth = depth }
+
+// queuedDraw is one draw call of a sorted scene; its geometry lives in the
+// queue's shared vertex and index slices.
+type queuedDraw struct {
+	layer      int32
+	depth      float32
+	key        float32 // depth, or bottom edge for SortLayerY
+	tex        core.Texture
+	sampler    core.Sampler
+	material   *Material
+	v0, nv     int32
+	i0, ni     int32
+	minX, minY float32 // bounds, for overlap tests
+	maxX, maxY float32
+}
+
+type sortQueue struct {
+	draws []queuedDraw
+	verts []Vertex
+	inds  []uint32
+	order []int32 // scratch for texture grouping
+}
+
+func (q *sortQueue) reset() {
+	q.draws = q.draws[:0]
+	q.verts = q.verts[:0]
+	q.inds = q.inds[:0]
+}
+
+func (q *sortQueue) add(rd *Renderer2D, layer int32, depth float32, tex core.Texture, mat *Material, verts []Vertex, inds []uint32) {
+	d := queuedDraw{
+		layer: layer, depth: depth, key: depth,
+		tex: tex, sampler: rd.sampler, material: mat,
+		v0: int32(len(q.verts)), nv: int32(len(verts)),
+		i0: int32(len(q.inds)), ni: int32(len(inds)),
+		minX: verts[0].X, minY: verts[0].Y, maxX: verts[0].X, maxY: verts[0].Y,
+	}
+	for _, v := range verts[1:] {
+		d.minX, d.maxX = min(d.minX, v.X), max(d.maxX, v.X)
+		d.minY, d.maxY = min(d.minY, v.Y), max(d.maxY, v.Y)
+	}
+	if rd.sortMode == SortLayerY {
+		d.key = d.maxY
+	}
+	q.draws = append(q.draws, d)
+	q.verts = append(q.verts, verts...)
+	q.inds = append(q.inds, inds...)
+}
+
+func (d *queuedDraw) overlaps(o *queuedDraw) bool {
+	return d.minX < o.maxX && o.minX < d.maxX && d.minY < o.maxY && o.minY < d.maxY
+}
+
+func (d *queuedDraw) sameKey(o *queuedDraw) bool {
+	return d.layer == o.layer && d.key == o.key && d.depth == o.depth
+}
+
+// drawQueue sorts the queued draws and emits them into batches.
+func (rd *Renderer2D) drawQueue() {
+	q := &rd.queue
+	if len(q.draws) == 0 {
+		return
+	}
+
+	slices.SortStableFunc(q.draws, func(a, b queuedDraw) int {
+		return cmp.Or(cmp.Compare(a.layer, b.layer), cmp.Compare(a.key, b.key), cmp.Compare(a.depth, b.depth))
+	})
+
+	// Group each run of equal keys by texture: a draw moves back behind the
+	// latest draw with its texture, unless that would jump an overlap.
+	q.order = q.order[:0]
+	for start := 0; start < len(q.draws); {
+		end := start + 1
+		for end < len(q.draws) && q.draws[end].sameKey(&q.draws[start]) {
+			end++
+		}
+		runStart := len(q.order)
+		for i := start; i < end; i++ {
+			d := &q.draws[i]
+			at := len(q.order)
+			for k := len(q.order) - 1; k >= runStart && len(q.order)-k <= groupLookback; k-- {
+				o := &q.draws[q.order[k]]
+				if o.tex == d.tex && o.sampler == d.sampler && o.material == d.material {
+					at = k + 1
+					break
+				}
+				if o.overlaps(d) {
+					break
+				}
+			}
+			q.order = slices.Insert(q.order, at, int32(i))
+		}
+		start = end
+	}
+
+	sampler := rd.sampler
+	for _, i := range q.order {
+		d := &q.draws[i]
+		if d.sampler != rd.sampler {
+			rd.flush()
+			rd.sampler = d.sampler
+		}
+		rd.emit(d.tex, d.material, q.verts[d.v0:d.v0+d.nv], q.inds[d.i0:d.i0+d.ni])
+	}
+	rd.flush()
+	rd.sampler = sampler
+	q.reset()
+}
@@ -0,0 +1,167 @@
+package renderer2d
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/hubastard/grove/engine/colors"
+)
+
+type testDraw struct {
+	tex   string
+	x, y  float32 // top-left of a 2x2 quad
+	layer int
+	depth float32
+}
+
+func TestSortOrder(t *testing.T) {
+	tests := []struct {
+		name  string
+		mode  SortMode
+		draws []testDraw
+		want  []string
+	}{
+		{"none keeps submission order", SortNone,
+			[]testDraw{{"A", 0, 0, 2, 0}, {"B", 10, 0, 1, 0}, {"A", 20, 0, 0, -1}},
+			[]string{"A@0", "B@10", "A@20"}},
+		{"layer then depth", SortLayerDepth,
+			[]testDraw{{"A", 0, 0, 1, 0}, {"A", 10, 0, 0, 5}, {"A", 20, 0, 0, -1}, {"A", 30, 0, 1, -2}},
+			[]string{"A@20", "A@10", "A@30", "A@0"}},
+		{"equal keys keep their order", SortLayerDepth,
+			[]testDraw{{"A", 20, 0, 0, 0}, {"A", 0, 0, 0, 0}, {"A", 10, 0, 0, 0}},
+			[]string{"A@20", "A@0", "A@10"}},
+		{"grouped by texture", SortLayerDepth,
+			[]testDraw{{"A", 0, 0, 0, 0}, {"B", 10, 0, 0, 0}, {"A", 20, 0, 0, 0}, {"B", 30, 0, 0, 0}, {"C", 40, 0, 0, 0}, {"A", 50, 0, 0, 0}},
+			[]string{"A@0", "A@20", "A@50", "B@10", "B@30", "C@40"}},
+		{"no move past an overlap", SortLayerDepth,
+			[]testDraw{{"A", 0, 0, 0, 0}, {"B", 10, 0, 0, 0}, {"A", 11, 1, 0, 0}},
+			[]string{"A@0", "B@10", "A@11"}},
+		{"touching edges don't overlap", SortLayerDepth,
+			[]testDraw{{"A", 0, 0, 0, 0}, {"B", 10, 0, 0, 0}, {"A", 12, 0, 0, 0}},
+			[]string{"A@0", "A@12", "B@10"}},
+		{"moves past other textures up to the overlap", SortLayerDepth,
+			[]testDraw{{"A", 0, 0, 0, 0}, {"B", 10, 0, 0, 0}, {"C", 20, 0, 0, 0}, {"A", 21, 0, 0, 0}},
+			[]string{"A@0", "B@10", "C@20", "A@21"}},
+		{"grouped only within equal keys", SortLayerDepth,
+			[]testDraw{{"A", 0, 0, 0, 0}, {"B", 10, 0, 0, 0}, {"A", 20, 0, 0, 1}},
+			[]string{"A@0", "B@10", "A@20"}},
+		{"bottom edge", SortLayerY,
+			[]testDraw{{"A", 0, 5, 0, 0}, {"A", 10, 0, 0, 0}, {"A", 20, 3, 0, 0}, {"A", 30, 9, -1, 0}},
+			[]string{"A@30", "A@10", "A@20", "A@0"}},
+		{"depth breaks bottom edge ties", SortLayerY,
+			[]testDraw{{"A", 0, 1, 0, 2}, {"A", 10, 1, 0, 1}},
+			[]string{"A@10", "A@0"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rd, r := newTestRenderer(t, 16)
+			rd.BeginScene(identity)
+			rd.SetSortMode(tc.mode)
+			for _, d := range tc.draws {
+				rd.SetLayer(d.layer)
+				rd.SetDepth(d.depth)
+				rd.DrawTexturedQuad(d.x+1, d.y+1, 2, 2, testTextures[d.tex], colors.White, 0)
+			}
+			rd.EndScene()
+			if got := r.quads(); !slices.Equal(got, tc.want) {
+				t.Errorf("drew %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSortGroupingSavesFlushes(t *testing.T) {
+	for _, tc := range []struct {
+		mode  SortMode
+		calls int
+	}{{SortNone, 4}, {SortLayerDepth, 2}} {
+		// one slot next to the white texture: every texture change flushes
+		rd, _ := newTestRenderer(t, maxMaterialTextures+2)
+		rd.BeginScene(identity)
+		rd.SetSortMode(tc.mode)
+		for i, name := range []string{"A", "B", "A", "B"} {
+			quad(rd, name, float32(10*i))
+		}
+		rd.EndScene()
+		if got := rd.Stats().DrawCalls; got != tc.calls {
+			t.Errorf("mode %d: %d draw calls, want %d", tc.mode, got, tc.calls)
+		}
+	}
+}
+
+func TestSortGroupLookback(t *testing.T) {
+	for _, between := range []int{groupLookback - 1, groupLookback} {
+		t.Run(fmt.Sprint(between), func(t *testing.T) {
+			rd, r := newTestRenderer(t, 16)
+			rd.BeginScene(identity)
+			rd.SetSortMode(SortLayerDepth)
+			quad(rd, "A", 0)
+			for i := range between {
+				quad(rd, "B", float32(10+10*i))
+			}
+			quad(rd, "A", -10)
+			rd.EndScene()
+
+			got := r.quads()
+			at := slices.Index(got, "A@-10")
+			grouped := between < groupLookback
+			if (at == 1) != grouped {
+				t.Errorf("%d draws between: second A drawn at %d, grouped = %v", between, at, grouped)
+			}
+		})
+	}
+}
+
+func TestSortLayerResetByBeginScene(t *testing.T) {
+	rd, r := newTestRenderer(t, 16)
+	rd.BeginScene(identity)
+	rd.SetSortMode(SortLayerDepth)
+	rd.SetLayer(5)
+	rd.SetDepth(3)
+	rd.EndScene()
+
+	rd.BeginScene(identity)
+	quad(rd, "A", 0)
+	rd.SetLayer(-1)
+	quad(rd, "A", 10)
+	rd.EndScene()
+	if got, want := r.quads(), []string{"A@10", "A@0"}; !slices.Equal(got, want) {
+		t.Errorf("drew %v, want %v", got, want)
+	}
+}
+
+func TestNineSliceOrder(t *testing.T) {
+	rd, r := newTestRenderer(t, 16)
+	rd.BeginScene(identity)
+	rd.SetSortMode(SortLayerDepth)
+	rd.SetLayer(1)
+	quad(rd, "A", 0)
+	sub := SubTexture2D{Texture: testTextures["B"], U1: 1, V1: 1} // no pixel size: one quad
+	rd.DrawNineSliceWithOptions(Rect{10, 0, 2, 2}, sub, Insets{}, colors.White, NineSliceOptions{Layer: -1})
+	rd.DrawNineSliceWithOptions(Rect{20, 0, 2, 2}, sub, Insets{}, colors.White, NineSliceOptions{Depth: -1})
+	rd.EndScene()
+	if got, want := r.quads(), []string{"B@10", "B@20", "A@0"}; !slices.Equal(got, want) {
+		t.Errorf("drew %v, want %v", got, want)
+	}
+}
+
+func TestSpriteOrder(t *testing.T) {
+	rd, r := newTestRenderer(t, 16)
+	rd.BeginScene(identity)
+	rd.SetSortMode(SortLayerDepth)
+	rd.SetDepth(1)
+	sprite := func(x float32, layer int, depth float32) {
+		rd.DrawSprite(Sprite{X: x, W: 2, H: 2, Tint: colors.White, Sub: SubTexture2D{Texture: testTextures["A"], U1: 1, V1: 1}, Layer: layer, Depth: depth})
+	}
+	sprite(0, 0, 0)    // layer 0, depth 1
+	sprite(10, 0, -2)  // depth -1
+	sprite(20, -1, 5)  // layer -1
+	sprite(30, 0, 0.5) // depth 1.5
+	rd.EndScene()
+	if got, want := r.quads(), []string{"A@20", "A@10", "A@0", "A@30"}; !slices.Equal(got, want) {
+		t.Errorf("drew %v, want %v", got, want)
+	}
+}
+
+var identity = [16]float32{1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1}
@@ -0,0 +1,137 @@
+package renderer2d
+
+import "github.com/hubastard/grove/engine/colors"
+
+// -------- Sprites --------
+
+// Affine2D is a 2D affine transform {a, b, c, d, tx, ty} mapping (x, y) to
+// (a*x + c*y + tx, b*x + d*y + ty).
+type Affine2D [6]float32
+
+// Identity2D returns the identity transform.
+func Identity2D() Affine2D { return Affine2D{1, 0, 0, 1, 0, 0} }
+
+// Translate2D returns a translation by (x, y).
+func Translate2D(x, y float32) Affine2D { return Affine2D{1, 0, 0, 1, x, y} }
+
+// Rotate2D returns a rotation by rad (clockwise on screen, as y points down).
+func Rotate2D(rad float32) Affine2D {
+	s, c := sincos(rad)
+	return Affine2D{c, s, -s, c, 0, 0}
+}
+
+// Scale2D returns a scale by (sx, sy).
+func Scale2D(sx, sy float32) Affine2D { return Affine2D{sx, 0, 0, sy, 0, 0} }
+
+// Skew2D returns a shear: x moves by y*kx and y by x*ky.
+func Skew2D(kx, ky float32) Affine2D { return Affine2D{1, ky, kx, 1, 0, 0} }
+
+// Mul returns m * n: n is applied first, then m.
+func (m Affine2D) Mul(n Affine2D) Affine2D {
+	return Affine2D{
+		m[0]*n[0] + m[2]*n[1],
+		m[1]*n[0] + m[3]*n[1],
+		m[0]*n[2] + m[2]*n[3],
+		m[1]*n[2] + m[3]*n[3],
+		m[0]*n[4] + m[2]*n[5] + m[4],
+		m[1]*n[4] + m[3]*n[5] + m[5],
+	}
+}
+
+// Apply transforms the point (x, y).
+func (m Affine2D) Apply(x, y float32) (float32, float32) {
+	return m[0]*x + m[2]*y + m[4], m[1]*x + m[3]*y + m[5]
+}
+
+// Common pivots, in fractions of the sprite size from its top-left corner.
+var (
+	PivotTopLeft = [2]float32{0, 0}
+	PivotCenter  = [2]float32{0.5, 0.5}
+	PivotBottom  = [2]float32{0.5, 1} // feet of a character
+)
+
+// Sprite describes one DrawSprite call. The zero Scale is 1; Tint has no
+// default (colors.White draws the texture as is).
+type Sprite struct {
+	X, Y  float32    // where the pivot lands
+	W, H  float32    // size before scale
+	Pivot [2]float32 // origin of rotation, scale, skew and flips; (0,0) is top-left
+
+	Rotation     float32    // radians
+	Scale        [2]float32 // per axis, 0 => 1
+	SkewX, SkewY float32    // shear factors, see Skew2D
+	FlipX, FlipY bool       // mirror about the pivot
+
+	// Transform, when set, replaces X, Y, Rotation, Scale and the skew: it
+	// maps pivot-relative sprite coordinates to world space.
+	Transform *Affine2D
+
+	Tint     colors.Color
+	Sub      SubTexture2D // nil Texture => solid Tint
+	Material *Material    // nil => the current one (SetMaterial)
+
+	Layer int     // added to the layer set by SetLayer (sorted modes only)
+	Depth float32 // added to the depth set by SetDepth (sorted modes only)
+}
+
+// Matrix returns the transform DrawSprite applies to pivot-relative
+// coordinates: scale, then skew, then rotation, then translation.
+func (s *Sprite) Matrix() Affine2D {
+	if s.Transform != nil {
+		return *s.Transform
+	}
+	sx, sy := s.Scale[0], s.Scale[1]
+	if sx == 0 {
+		sx = 1
+	}
+	if sy == 0 {
+		sy = 1
+	}
+	sin, cos := sincos(s.Rotation)
+	// Rotate2D(r) * Skew2D(kx, ky) * Scale2D(sx, sy), translated
+	a, b := sx*(cos-sin*s.SkewY), sx*(sin+cos*s.SkewY)
+	c, d := sy*(cos*s.SkewX-sin), sy*(sin*s.SkewX+cos)
+	return Affine2D{a, b, c, d, s.X, s.Y}
+}
+
+// DrawSprite draws s as one quad.
+func (rd *Renderer2D) DrawSprite(s Sprite) {
+	m := s.Matrix()
+
+	// corners relative to the pivot, mirrored about it when flipped
+	x0, y0 := -s.Pivot[0]*s.W, -s.Pivot[1]*s.H
+	x1, y1 := x0+s.W, y0+s.H
+	if s.FlipX {
+		x0, x1 = -x0, -x1
+	}
+	if s.FlipY {
+		y0, y1 = -y0, -y1
+	}
+
+	tex, u0, v0, u1, v1 := s.Sub.Texture, s.Sub.U0, s.Sub.V0, s.Sub.U1, s.Sub.V1
+	if tex == nil {
+		tex, u0, v0, u1, v1 = rd.white, 0, 0, 1, 1
+	}
+	mat := s.Material
+	if mat == nil {
+		mat = rd.material
+	} else {
+		checkMaterial(mat)
+	}
+
+	packed := PackColor(s.Tint)
+
+	corners := [vertsPerQuad][4]float32{ // TL, TR, BL, BR
+		{x0, y0, u0, v0},
+		{x1, y0, u1, v0},
+		{x0, y1, u0, v1},
+		{x1, y1, u1, v1},
+	}
+	var verts [vertsPerQuad]Vertex
+	for i, p := range corners {
+		x, y := m.Apply(p[0], p[1])
+		verts[i] = Vertex{X: x, Y: y, Color: packed, U: p[2], V: p[3]}
+	}
+	rd.pushAt(rd.layer+int32(s.Layer), rd.depth+s.Depth, tex, mat, verts[:], quadIndices[:])
+	rd.stats.QuadCount++
+}
@@ -7,6 +7,12 @@ type SubTexture2D struct {
 	Texture core.Texture
 	U0, V0  float32
 	U1, V1  float32
+	W, H    float32 // size in pixels, 0 if unknown (needed by DrawNineSlice)
+}
+
+// FromTexture covers a whole w x h texture.
+func FromTexture(tex core.Texture, w, h int) SubTexture2D {
+	return SubTexture2D{Texture: tex, U1: 1, V1: 1, W: float32(w), H: float32(h)}
 }
 
 // FromPixels builds a subtexture from pixel coordinates within an atlas.
@@ -15,7 +21,7 @@ func FromPixels(tex core.Texture, x, y, w, h, atlasW, atlasH int) SubTexture2D {
 	v0 := float32(y) / float32(atlasH)
 	u1 := float32(x+w) / float32(atlasW)
 	v1 := float32(y+h) / float32(atlasH)
-	return SubTexture2D{Texture: tex, U0: u0, V0: v0, U1: u1, V1: v1}
+	return SubTexture2D{Texture: tex, U0: u0, V0: v0, U1: u1, V1: v1, W: float32(w), H: float32(h)}
 }
 
 // FromGrid builds a subtexture from tile grid coordinates (cx,cy) of cell size (cw,ch).
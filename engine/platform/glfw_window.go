@@ -22,12 +22,19 @@ func NewGLFWWindow(cfg core.Config, onEvent func(core.Event)) (*GLFWWindow, erro
 		return nil, err
 	}
 
-	// GL 3.2+ core profile (Mac requires forward-compatible flag).
+	// GL 3.3 core profile, which the backend is written against (Mac requires
+	// forward-compatible flag).
 	glfw.WindowHint(glfw.ContextVersionMajor, 3)
-	glfw.WindowHint(glfw.ContextVersionMinor, 2)
+	glfw.WindowHint(glfw.ContextVersionMinor, 3)
 	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
 	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
-	glfw.WindowHint(glfw.Samples, 0)
+	glfw.WindowHint(glfw.Samples, max(cfg.MSAASamples, 0))
+	if cfg.LinearColor {
+		glfw.WindowHint(glfw.SRGBCapable, glfw.True)
+	}
+	if cfg.GLDebug {
+		glfw.WindowHint(glfw.OpenGLDebugContext, glfw.True)
+	}
 
 	win, err := glfw.CreateWindow(cfg.Width, cfg.Height, cfg.Title, nil, nil)
 	if err != nil {
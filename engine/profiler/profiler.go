@@ -17,6 +17,9 @@ import (
 
 // -------- public API --------
 
+// Enabled reports whether the profiler is compiled in ("profile" build tag).
+const Enabled = true
+
 var m runtime.MemStats
 
 // Init must be called once (e.g., on app start) with a capacity (#spans).
@@ -26,6 +29,42 @@ func Init(capacity int) {
 		capacity = 1 << 20
 	}
 	evrb.init(capacity)
+	gpurb.init(capacity)
+}
+
+// GPUScope is a resolved GPU region, converted to CPU-clock nanoseconds
+// (time.Now().UnixNano()) so it lines up with CPU scopes.
+type GPUScope struct {
+	Name           string
+	StartNS, EndNS int64
+}
+
+// RecordGPU adds one frame of GPU scopes to the "GPU" track. Scopes must be
+// in begin order with parents before their children.
+func RecordGPU(scopes []GPUScope) {
+	if !gpurb.ready.Load() {
+		return
+	}
+	// Re-nest begin-ordered scopes into open/close events.
+	type open struct {
+		id    int
+		endNS int64
+	}
+	var stackBuf [32]open
+	stack := stackBuf[:0]
+	for _, sc := range scopes {
+		for len(stack) > 0 && stack[len(stack)-1].endNS <= sc.StartNS {
+			top := stack[len(stack)-1]
+			gpurb.push(evEntry{AtNS: top.endNS, FrameID: top.id, Open: false})
+			stack = stack[:len(stack)-1]
+		}
+		id := intern(sc.Name)
+		gpurb.push(evEntry{AtNS: sc.StartNS, FrameID: id, Open: true})
+		stack = append(stack, open{id: id, endNS: sc.EndNS})
+	}
+	for i := len(stack) - 1; i >= 0; i-- {
+		gpurb.push(evEntry{AtNS: stack[i].endNS, FrameID: stack[i].id, Open: false})
+	}
 }
 
 // Scope is a lightweight handle for an active profiler region.
@@ -64,9 +103,10 @@ func OpenProfilerGraph() (string, error) {
 	if len(evs) == 0 {
 		return "", fmt.Errorf("profiler: no events to dump")
 	}
+	gpuEvs := gpurb.snapshot()
 
 	profilePath := filepath.Join(os.TempDir(), "grave.profile.speedscope.json")
-	if err := dumpSpeedscopeEvents(evs, profilePath); err != nil {
+	if err := dumpSpeedscopeEvents(evs, gpuEvs, profilePath); err != nil {
 		return "", err
 	}
 
@@ -148,7 +188,8 @@ func (r *evRing) snapshot() []evEntry {
 	return out
 }
 
-var evrb evRing
+var evrb evRing  // CPU scopes
+var gpurb evRing // GPU scopes (RecordGPU)
 
 // ---------- string interner ----------
 
@@ -211,7 +252,7 @@ type ssEvent struct {
 	Frame int    `json:"frame"` // frame index
 }
 
-func dumpSpeedscopeEvents(evs []evEntry, path string) error {
+func dumpSpeedscopeEvents(evs, gpuEvs []evEntry, path string) error {
 	// snapshot frames
 	muFrames.Lock()
 	fs := make([]ssFrame, len(frames))
@@ -224,7 +265,50 @@ func dumpSpeedscopeEvents(evs []evEntry, path string) error {
 		return fmt.Errorf("no events")
 	}
 
+	// Both tracks share the same time origin so they line up in speedscope.
 	base := evs[0].AtNS
+	if len(gpuEvs) > 0 && gpuEvs[0].AtNS < base {
+		base = gpuEvs[0].AtNS
+	}
+
+	cpu := buildProfile("Go Engine (evented)", evs, base)
+	if len(cpu.Events) == 0 {
+		return fmt.Errorf("no usable events after filtering")
+	}
+	profiles := []ssProfile{cpu}
+	if gpu := buildProfile("GPU", gpuEvs, base); len(gpu.Events) > 0 {
+		profiles = append(profiles, gpu)
+	}
+
+	doc := ssFile{
+		Schema:             "https://www.speedscope.app/file-format-schema.json",
+		Shared:             ssShared{Frames: fs},
+		Profiles:           profiles,
+		ActiveProfileIndex: 0,
+		Exporter:           "goengine-profiler",
+		Name:               "Go Engine capture",
+	}
+
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(&doc); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// buildProfile converts write-ordered events into a balanced evented profile.
+func buildProfile(name string, evs []evEntry, base int64) ssProfile {
 	endUS := int64(0)
 
 	// stream in write order with small stack filter
@@ -267,40 +351,12 @@ func dumpSpeedscopeEvents(evs []evEntry, path string) error {
 		// stack cleared implicitly; endUS unchanged (atUS == lastUS)
 	}
 
-	if len(out) == 0 {
-		return fmt.Errorf("no usable events after filtering")
-	}
-
-	doc := ssFile{
-		Schema: "https://www.speedscope.app/file-format-schema.json",
-		Shared: ssShared{Frames: fs},
-		Profiles: []ssProfile{{
-			Type:       "evented",
-			Name:       "Go Engine (evented)",
-			Unit:       "microseconds",
-			StartValue: 0,
-			EndValue:   endUS,
-			Events:     out,
-		}},
-		ActiveProfileIndex: 0,
-		Exporter:           "goengine-profiler",
-		Name:               "Go Engine capture",
+	return ssProfile{
+		Type:       "evented",
+		Name:       name,
+		Unit:       "microseconds",
+		StartValue: 0,
+		EndValue:   endUS,
+		Events:     out,
 	}
-
-	tmp := path + ".tmp"
-	f, err := os.Create(tmp)
-	if err != nil {
-		return err
-	}
-	enc := json.NewEncoder(f)
-	enc.SetIndent("", "  ")
-	if err := enc.Encode(&doc); err != nil {
-		_ = f.Close()
-		_ = os.Remove(tmp)
-		return err
-	}
-	if err := f.Close(); err != nil {
-		return err
-	}
-	return os.Rename(tmp, path)
 }
@@ -6,13 +6,21 @@ import "runtime"
 
 // Stubbed no-op versions when the "profile" build tag is not set.
 
+const Enabled = false
+
 type Scope struct{}
 
+type GPUScope struct {
+	Name           string
+	StartNS, EndNS int64
+}
+
 var m runtime.MemStats
 
 func Init(capacity int)                  {}
 func Start(name string) Scope            { return Scope{} }
 func (Scope) End()                       {}
+func RecordGPU(scopes []GPUScope)        {}
 func OpenProfilerGraph() (string, error) { return "", nil }
 
 func MemoryUsage() uint64 {
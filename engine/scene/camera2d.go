@@ -51,6 +51,21 @@ func (c *OrthoCamera2D) SetZoom(z float32) {
 	c.dirty = true
 }
 
+// Bounds returns the world-space box holding the view (rotation included).
+func (c *OrthoCamera2D) Bounds() (minX, minY, maxX, maxY float32) {
+	z := c.Zoom
+	s, co := float32(math.Sin(float64(c.RotationRad))), float32(math.Cos(float64(c.RotationRad)))
+	minX, minY = float32(math.Inf(1)), float32(math.Inf(1))
+	maxX, maxY = float32(math.Inf(-1)), float32(math.Inf(-1))
+	for _, p := range [4][2]float32{{c.Left, c.Top}, {c.Right, c.Top}, {c.Left, c.Bottom}, {c.Right, c.Bottom}} {
+		x, y := p[0]/z, p[1]/z
+		wx, wy := x*co-y*s+c.X, x*s+y*co+c.Y
+		minX, maxX = min(minX, wx), max(maxX, wx)
+		minY, maxY = min(minY, wy), max(maxY, wy)
+	}
+	return minX, minY, maxX, maxY
+}
+
 func (c *OrthoCamera2D) VP() [16]float32 {
 	if c.dirty {
 		c.Recalculate()
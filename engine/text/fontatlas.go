@@ -136,8 +136,8 @@ func LoadTTF(r core.Renderer, ttfRelPath string, sizePx float32) (*Font, error)
 			break
 		}
 		atlasSize *= 2
-		if atlasSize > 4096 {
-			return nil, fmt.Errorf("font atlas too large (>%d)", 4096)
+		if maxSize := r.Caps().MaxTextureSize; atlasSize > maxSize {
+			return nil, fmt.Errorf("font atlas too large (>%d, device limit)", maxSize)
 		}
 	}
 
@@ -206,6 +206,8 @@ func LoadTTF(r core.Renderer, ttfRelPath string, sizePx float32) (*Font, error)
 		MagFilter: "nearest",
 		WrapU:     "clamp",
 		WrapV:     "clamp",
+		Label:     "font atlas " + ttfRelPath,
+		NonColor:  true, // coverage
 	})
 	if err != nil {
 		return nil, err
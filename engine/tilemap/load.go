@@ -0,0 +1,172 @@
+package tilemap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/hubastard/grove/engine/anim"
+)
+
+// -------- Shared by the .tmj and .tmx loaders --------
+
+// dataChunk is tile data covering tiles [x, x+w) x [y, y+h); a finite
+// layer is one chunk at (0, 0).
+type dataChunk struct {
+	x, y, w, h int
+	gids       []uint32
+}
+
+// setTiles sizes the layer to hold the chunks and copies them in.
+func (l *Layer) setTiles(chunks []dataChunk) error {
+	if len(chunks) == 0 {
+		return nil
+	}
+	x0, y0 := chunks[0].x, chunks[0].y
+	x1, y1 := x0+chunks[0].w, y0+chunks[0].h
+	for _, c := range chunks {
+		if len(c.gids) != c.w*c.h {
+			return fmt.Errorf("layer %q: %d tiles for %dx%d", l.Name, len(c.gids), c.w, c.h)
+		}
+		x0, y0 = min(x0, c.x), min(y0, c.y)
+		x1, y1 = max(x1, c.x+c.w), max(y1, c.y+c.h)
+	}
+	l.X, l.Y, l.W, l.H = x0, y0, x1-x0, y1-y0
+	l.gids = make([]uint32, l.W*l.H)
+	for _, c := range chunks {
+		for row := 0; row < c.h; row++ {
+			dst := (c.y-y0+row)*l.W + c.x - x0
+			copy(l.gids[dst:dst+c.w], c.gids[row*c.w:(row+1)*c.w])
+		}
+	}
+	return nil
+}
+
+// decodeData decodes csv or base64 (optionally zlib or gzip compressed)
+// tile data.
+func decodeData(encoding, compression, text string) ([]uint32, error) {
+	switch encoding {
+	case "csv":
+		var gids []uint32
+		for _, f := range strings.Split(text, ",") {
+			f = strings.TrimSpace(f)
+			if f == "" {
+				continue
+			}
+			v, err := strconv.ParseUint(f, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("csv tile data: %w", err)
+			}
+			gids = append(gids, uint32(v))
+		}
+		return gids, nil
+	case "base64":
+	default:
+		return nil, fmt.Errorf("unknown tile data encoding %q", encoding)
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(text))
+	if err != nil {
+		return nil, fmt.Errorf("base64 tile data: %w", err)
+	}
+	var rd io.Reader
+	switch compression {
+	case "":
+	case "zlib":
+		rd, err = zlib.NewReader(bytes.NewReader(raw))
+	case "gzip":
+		rd, err = gzip.NewReader(bytes.NewReader(raw))
+	default:
+		return nil, fmt.Errorf("unsupported tile data compression %q", compression)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s tile data: %w", compression, err)
+	}
+	if rd != nil {
+		if raw, err = io.ReadAll(rd); err != nil {
+			return nil, fmt.Errorf("%s tile data: %w", compression, err)
+		}
+	}
+	if len(raw)%4 != 0 {
+		return nil, fmt.Errorf("tile data of %d bytes", len(raw))
+	}
+	gids := make([]uint32, len(raw)/4)
+	for i := range gids {
+		gids[i] = binary.LittleEndian.Uint32(raw[i*4:])
+	}
+	return gids, nil
+}
+
+// loadExternalTileset reads a .tsj or .tsx tileset; path is relative to dir.
+func loadExternalTileset(dir, source string, firstGID uint32) (*Tileset, error) {
+	path := filepath.Join(dir, source)
+	var (
+		ts  *Tileset
+		err error
+	)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".tsj", ".json":
+		ts, err = loadTSJ(path)
+	case ".tsx":
+		ts, err = loadTSX(path)
+	default:
+		err = fmt.Errorf("unknown tileset format")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("tileset %q: %w", path, err)
+	}
+	ts.FirstGID = firstGID
+	return ts, nil
+}
+
+// tileAnimation makes the clip of an animated tile; frame images are set
+// once the tileset image is loaded.
+func tileAnimation(t *Tile, ids, durationsMs []int) {
+	t.Animation = &anim.Clip{Name: "tile " + strconv.Itoa(t.ID), Frames: make([]anim.Frame, len(ids))}
+	t.frameIDs = ids
+	for i, ms := range durationsMs {
+		t.Animation.Frames[i].Duration = float32(ms) / 1000
+	}
+}
+
+// parsePoints parses the "x,y x,y ..." points of a .tmx polygon.
+func parsePoints(s string) ([][2]float32, error) {
+	var pts [][2]float32
+	for _, p := range strings.Fields(s) {
+		xs, ys, ok := strings.Cut(p, ",")
+		if !ok {
+			return nil, fmt.Errorf("bad point %q", p)
+		}
+		x, err := strconv.ParseFloat(xs, 32)
+		if err != nil {
+			return nil, fmt.Errorf("bad point %q", p)
+		}
+		y, err := strconv.ParseFloat(ys, 32)
+		if err != nil {
+			return nil, fmt.Errorf("bad point %q", p)
+		}
+		pts = append(pts, [2]float32{float32(x), float32(y)})
+	}
+	return pts, nil
+}
+
+// propertyValue converts a .tmx property value to its Properties type.
+func propertyValue(typ, value string) (any, error) {
+	switch typ {
+	case "int", "object":
+		return strconv.Atoi(value)
+	case "float":
+		return strconv.ParseFloat(value, 64)
+	case "bool":
+		return strconv.ParseBool(value)
+	default: // string, color, file
+		return value, nil
+	}
+}
@@ -0,0 +1,193 @@
+package tilemap
+
+import (
+	"math"
+	"slices"
+
+	"github.com/hubastard/grove/engine/colors"
+	"github.com/hubastard/grove/engine/core"
+	"github.com/hubastard/grove/engine/gfx/renderer2d"
+	"github.com/hubastard/grove/engine/scene"
+)
+
+// -------- Chunks --------
+
+// Tile layers are drawn in chunks of chunkSize x chunkSize tiles whose
+// vertices are built once and rebuilt only after SetTile or an animation
+// frame change.
+const chunkSize = 16
+
+type chunk struct {
+	verts    []renderer2d.Vertex
+	inds     []uint32
+	groups   []chunkGroup // one per tileset texture
+	dirty    bool
+	animated bool // holds animated tiles
+}
+
+// chunkGroup is the part of a chunk drawn with one texture.
+type chunkGroup struct {
+	tex            core.Texture
+	v0, v1, i0, i1 int
+}
+
+func (l *Layer) initChunks() {
+	l.chunkCols = (l.W + chunkSize - 1) / chunkSize
+	rows := (l.H + chunkSize - 1) / chunkSize
+	l.chunks = make([]chunk, l.chunkCols*rows)
+	for i := range l.chunks {
+		l.chunks[i].dirty = true
+	}
+}
+
+// buildChunk fills chunk (cx, cy) with a quad per tile, grouped by tileset.
+func (l *Layer) buildChunk(cx, cy int) {
+	m := l.m
+	c := &l.chunks[cy*l.chunkCols+cx]
+	c.verts, c.inds, c.groups = c.verts[:0], c.inds[:0], c.groups[:0]
+	c.dirty, c.animated = false, false
+
+	for len(m.buckets) < len(m.Tilesets) {
+		m.buckets = append(m.buckets, nil)
+	}
+	for i := range m.buckets {
+		m.buckets[i] = m.buckets[i][:0]
+	}
+
+	color := renderer2d.PackColor(colors.White.WithAlpha(l.Opacity))
+	x0, y0 := cx*chunkSize, cy*chunkSize
+	for y := y0; y < min(y0+chunkSize, l.H); y++ {
+		for x := x0; x < min(x0+chunkSize, l.W); x++ {
+			gid := l.gids[y*l.W+x]
+			ts, id := m.Tileset(gid)
+			if ts == nil {
+				continue
+			}
+			if t := ts.Tiles[id]; t != nil && t.Animation != nil {
+				c.animated = true
+			}
+			// tiles sit on the bottom-left corner of their cell
+			px := float32((l.X+x)*m.TileW) + l.OffsetX + ts.OffsetX
+			py := float32((l.Y+y+1)*m.TileH) + l.OffsetY + ts.OffsetY
+			q := tileQuad(ts.frame(id), gid, color, px, py-float32(ts.TileH), px+float32(ts.TileW), py)
+
+			b := slices.Index(m.Tilesets, ts)
+			m.buckets[b] = append(m.buckets[b], q[:]...)
+		}
+	}
+
+	for b, verts := range m.buckets {
+		if len(verts) == 0 {
+			continue
+		}
+		g := chunkGroup{tex: m.Tilesets[b].Texture, v0: len(c.verts), i0: len(c.inds)}
+		for q := 0; q < len(verts); q += 4 {
+			base := uint32(q)
+			c.inds = append(c.inds, base, base+2, base+1, base+1, base+2, base+3)
+		}
+		c.verts = append(c.verts, verts...)
+		g.v1, g.i1 = len(c.verts), len(c.inds)
+		c.groups = append(c.groups, g)
+	}
+}
+
+// tileQuad returns the corners TL, TR, BL, BR of a tile with the flips of
+// gid applied to its UVs: diagonal first, then horizontal, then vertical.
+func tileQuad(sub renderer2d.SubTexture2D, gid uint32, color [4]uint8, x0, y0, x1, y1 float32) [4]renderer2d.Vertex {
+	var q [4]renderer2d.Vertex
+	for i := range q {
+		gx, gy := float32(i&1), float32(i>>1)
+		q[i] = renderer2d.Vertex{X: x0 + (x1-x0)*gx, Y: y0 + (y1-y0)*gy, Color: color}
+		if gid&FlipV != 0 {
+			gy = 1 - gy
+		}
+		if gid&FlipH != 0 {
+			gx = 1 - gx
+		}
+		if gid&FlipD != 0 {
+			gx, gy = gy, gx
+		}
+		q[i].U = sub.U0 + (sub.U1-sub.U0)*gx
+		q[i].V = sub.V0 + (sub.V1-sub.V0)*gy
+	}
+	return q
+}
+
+// -------- Drawing --------
+
+// Draw draws the visible layers in order.
+func (m *Map) Draw(rd *renderer2d.Renderer2D, cam *scene.OrthoCamera2D) {
+	for _, l := range m.Layers {
+		l.Draw(rd, cam)
+	}
+}
+
+// Draw draws the chunks (or tile objects) of the layer in view of cam,
+// rebuilding the chunks that changed.
+func (l *Layer) Draw(rd *renderer2d.Renderer2D, cam *scene.OrthoCamera2D) {
+	if !l.Visible || l.Opacity <= 0 {
+		return
+	}
+	minX, minY, maxX, maxY := cam.Bounds()
+	if l.Kind == ObjectLayer {
+		l.drawObjects(rd, minX, minY, maxX, maxY)
+		return
+	}
+
+	// cells whose tiles may reach into the view
+	m := l.m
+	over := m.over
+	cw, ch := float32(m.TileW*chunkSize), float32(m.TileH*chunkSize)
+	ox, oy := float32(l.X*m.TileW)+l.OffsetX, float32(l.Y*m.TileH)+l.OffsetY
+	cx0 := max(floorDiv(minX-over[2]-ox, cw), 0)
+	cx1 := min(floorDiv(maxX+over[0]-ox, cw), l.chunkCols-1)
+	cy0 := max(floorDiv(minY-over[3]-oy, ch), 0)
+	cy1 := min(floorDiv(maxY+over[1]-oy, ch), len(l.chunks)/max(l.chunkCols, 1)-1)
+
+	for cy := cy0; cy <= cy1; cy++ {
+		for cx := cx0; cx <= cx1; cx++ {
+			c := &l.chunks[cy*l.chunkCols+cx]
+			if c.dirty {
+				l.buildChunk(cx, cy)
+			}
+			for _, g := range c.groups {
+				rd.DrawTriangles(g.tex, c.verts[g.v0:g.v1], c.inds[g.i0:g.i1])
+			}
+		}
+	}
+}
+
+func floorDiv(v, d float32) int { return int(math.Floor(float64(v / d))) }
+
+// drawObjects draws the tile objects of an object layer.
+func (l *Layer) drawObjects(rd *renderer2d.Renderer2D, minX, minY, maxX, maxY float32) {
+	tint := colors.White.WithAlpha(l.Opacity)
+	for i := range l.Objects {
+		o := &l.Objects[i]
+		ts, id := l.m.Tileset(o.GID)
+		if ts == nil || !o.Visible {
+			continue
+		}
+		x, y := o.X+l.OffsetX+ts.OffsetX, o.Y+l.OffsetY+ts.OffsetY
+		// rotation keeps the object within its largest side of (x, y)
+		r := max(o.W, o.H)
+		if x+r < minX || x-r > maxX || y+r < minY || y-r > maxY {
+			continue
+		}
+		// flipped in place, not about the pivot
+		sub := ts.frame(id)
+		if o.GID&FlipH != 0 {
+			sub.U0, sub.U1 = sub.U1, sub.U0
+		}
+		if o.GID&FlipV != 0 {
+			sub.V0, sub.V1 = sub.V1, sub.V0
+		}
+		rd.DrawSprite(renderer2d.Sprite{
+			X: x, Y: y, W: o.W, H: o.H,
+			Pivot:    [2]float32{0, 1},
+			Rotation: o.Rotation * math.Pi / 180,
+			Tint:     tint,
+			Sub:      sub,
+		})
+	}
+}
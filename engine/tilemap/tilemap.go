@@ -0,0 +1,355 @@
+// Package tilemap loads Tiled maps (.tmj, .tmx) and draws them through
+// renderer2d in chunks of prebuilt vertices.
+package tilemap
+
+import (
+	"cmp"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/hubastard/grove/engine/anim"
+	"github.com/hubastard/grove/engine/assets"
+	"github.com/hubastard/grove/engine/core"
+	"github.com/hubastard/grove/engine/gfx/renderer2d"
+)
+
+// Flip flags in the high bits of a tile GID, as stored by Tiled.
+const (
+	FlipH uint32 = 0x80000000 // mirror horizontally
+	FlipV uint32 = 0x40000000 // mirror vertically
+	FlipD uint32 = 0x20000000 // swap x and y (applied first; with FlipH/FlipV, 90° rotations)
+
+	flipHex uint32 = 0x10000000 // hexagonal maps only, ignored
+
+	// GIDMask clears the flip flags.
+	GIDMask = ^(FlipH | FlipV | FlipD | flipHex)
+)
+
+// Map is an orthogonal Tiled map. World units are pixels, y down, with the
+// top-left of tile (0, 0) at the origin.
+type Map struct {
+	Width, Height int // in tiles (the initial size for infinite maps)
+	TileW, TileH  int // in pixels
+	Tilesets      []*Tileset
+	Layers        []*Layer // group layers are flattened into their children
+	Properties    Properties
+
+	animated []*Tile    // tiles with an animation
+	over     [4]float32 // how far tiles reach out of their cell: left, top, right, bottom
+	buckets  [][]renderer2d.Vertex
+}
+
+// Tileset is a grid of tiles cut from one image.
+type Tileset struct {
+	Name             string
+	FirstGID         uint32
+	TileW, TileH     int
+	Columns, Count   int
+	Margin, Spacing  int
+	OffsetX, OffsetY float32 // drawing offset of every tile
+	Image            string  // image path as loaded
+	ImageW, ImageH   int
+	Texture          core.Texture
+	Tiles            map[int]*Tile // by local ID, for tiles with data
+	Properties       Properties
+}
+
+// Tile is per-tile data of a tileset.
+type Tile struct {
+	ID         int // local to the tileset
+	Class      string
+	Properties Properties
+	Animation  *anim.Clip // nil if not animated
+
+	frameIDs []int // local tile of each animation frame
+	player   anim.Animator
+}
+
+// Sub returns the image of local tile id.
+func (ts *Tileset) Sub(id int) renderer2d.SubTexture2D {
+	col, row := id%ts.Columns, id/ts.Columns
+	x := ts.Margin + col*(ts.TileW+ts.Spacing)
+	y := ts.Margin + row*(ts.TileH+ts.Spacing)
+	return renderer2d.FromPixels(ts.Texture, x, y, ts.TileW, ts.TileH, ts.ImageW, ts.ImageH)
+}
+
+// frame returns the image of local tile id at the current animation time.
+func (ts *Tileset) frame(id int) renderer2d.SubTexture2D {
+	if t := ts.Tiles[id]; t != nil && t.Animation != nil {
+		return t.player.Sub()
+	}
+	return ts.Sub(id)
+}
+
+type LayerKind int
+
+const (
+	TileLayer LayerKind = iota
+	ObjectLayer
+)
+
+// Layer is a tile layer or an object layer.
+type Layer struct {
+	Kind             LayerKind
+	Name, Class      string
+	Visible          bool
+	Opacity          float32
+	OffsetX, OffsetY float32 // in pixels, group offsets included
+	Properties       Properties
+
+	// Tile layers: the grid spans tiles [X, X+W) x [Y, Y+H); X and Y are
+	// non-zero only for infinite maps.
+	X, Y, W, H int
+	gids       []uint32
+	chunks     []chunk
+	chunkCols  int
+
+	// Object layers.
+	Objects []Object
+
+	m *Map
+}
+
+// Object is an object of an object layer. Tile objects (GID != 0) are
+// drawn, the others are data.
+type Object struct {
+	ID          int
+	Name, Class string
+	X, Y, W, H  float32 // in pixels; tile objects sit on their bottom-left corner
+	Rotation    float32 // degrees, clockwise
+	GID         uint32  // tile with flip flags, or 0
+	Visible     bool
+	Point       bool
+	Ellipse     bool
+	Polygon     [][2]float32 // relative to X, Y
+	Polyline    [][2]float32 // relative to X, Y
+	Properties  Properties
+}
+
+// Load reads a map from assets/maps (.tmj or .tmx) with its tilesets
+// (inline, .tsj or .tsx) and uploads their images with nearest filtering.
+// Image collection tilesets, object templates and non-orthogonal maps are
+// not supported.
+func Load(r core.Renderer, relPath string) (*Map, error) {
+	path := filepath.Join("assets", "maps", relPath)
+	var (
+		m   *Map
+		err error
+	)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".tmj", ".json":
+		m, err = loadTMJ(path)
+	case ".tmx":
+		m, err = loadTMX(path)
+	default:
+		err = fmt.Errorf("unknown map format")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load map %q: %w", path, err)
+	}
+	if err := m.init(r); err != nil {
+		return nil, fmt.Errorf("load map %q: %w", path, err)
+	}
+	return m, nil
+}
+
+// init uploads the tileset images and prepares animations and chunks.
+func (m *Map) init(r core.Renderer) error {
+	slices.SortStableFunc(m.Tilesets, func(a, b *Tileset) int { return cmp.Compare(a.FirstGID, b.FirstGID) })
+	textures := map[string]*Tileset{}
+	for _, ts := range m.Tilesets {
+		if ts.Image == "" {
+			return fmt.Errorf("tileset %q: image collection tilesets are not supported", ts.Name)
+		}
+		if ts.TileW <= 0 || ts.TileH <= 0 {
+			return fmt.Errorf("tileset %q: invalid tile size %dx%d", ts.Name, ts.TileW, ts.TileH)
+		}
+		if same := textures[ts.Image]; same != nil {
+			ts.Texture, ts.ImageW, ts.ImageH = same.Texture, same.ImageW, same.ImageH
+		} else {
+			w, h, pix, err := assets.LoadPNGFile(ts.Image)
+			if err != nil {
+				return fmt.Errorf("tileset %q: %w", ts.Name, err)
+			}
+			ts.Texture, err = r.CreateTexture(core.TextureDesc{
+				Width:     w,
+				Height:    h,
+				Format:    core.TextureRGBA8,
+				Pixels:    pix,
+				MinFilter: "nearest",
+				MagFilter: "nearest",
+				WrapU:     "clamp",
+				WrapV:     "clamp",
+				Label:     filepath.Base(ts.Image),
+			})
+			if err != nil {
+				return fmt.Errorf("tileset %q: %w", ts.Name, err)
+			}
+			ts.ImageW, ts.ImageH = w, h
+			textures[ts.Image] = ts
+		}
+		if ts.Columns <= 0 {
+			ts.Columns = max((ts.ImageW-2*ts.Margin+ts.Spacing)/(ts.TileW+ts.Spacing), 1)
+		}
+
+		for _, t := range ts.Tiles {
+			if t.Animation == nil {
+				continue
+			}
+			for i, id := range t.frameIDs {
+				f := &t.Animation.Frames[i]
+				f.Sub = ts.Sub(id)
+				f.Source = renderer2d.Rect{W: f.Sub.W, H: f.Sub.H}
+			}
+			t.player.Play(t.Animation)
+			m.animated = append(m.animated, t)
+		}
+
+		// tiles larger than the grid grow up and right from their cell
+		m.over[0] = max(m.over[0], -ts.OffsetX)
+		m.over[1] = max(m.over[1], float32(ts.TileH-m.TileH)-ts.OffsetY)
+		m.over[2] = max(m.over[2], float32(ts.TileW-m.TileW)+ts.OffsetX)
+		m.over[3] = max(m.over[3], ts.OffsetY)
+	}
+
+	for _, l := range m.Layers {
+		l.m = m
+		if l.Kind == TileLayer {
+			l.initChunks()
+		}
+	}
+	return nil
+}
+
+// Tileset returns the tileset of gid and the tile's local ID, or nil for
+// an empty or unknown tile.
+func (m *Map) Tileset(gid uint32) (*Tileset, int) {
+	gid &= GIDMask
+	if gid == 0 {
+		return nil, 0
+	}
+	for i := len(m.Tilesets) - 1; i >= 0; i-- {
+		ts := m.Tilesets[i]
+		if gid >= ts.FirstGID {
+			id := int(gid - ts.FirstGID)
+			if ts.Count > 0 && id >= ts.Count {
+				return nil, 0
+			}
+			return ts, id
+		}
+	}
+	return nil, 0
+}
+
+// TileData returns the per-tile data of gid, or nil.
+func (m *Map) TileData(gid uint32) *Tile {
+	ts, id := m.Tileset(gid)
+	if ts == nil {
+		return nil
+	}
+	return ts.Tiles[id]
+}
+
+// Layer returns the first layer named name, or nil.
+func (m *Map) Layer(name string) *Layer {
+	for _, l := range m.Layers {
+		if l.Name == name {
+			return l
+		}
+	}
+	return nil
+}
+
+// Object returns the first object named name in any object layer, or nil.
+func (m *Map) Object(name string) *Object {
+	for _, l := range m.Layers {
+		for i := range l.Objects {
+			if l.Objects[i].Name == name {
+				return &l.Objects[i]
+			}
+		}
+	}
+	return nil
+}
+
+// Update advances the tile animations by dt seconds; call it from OnUpdate.
+func (m *Map) Update(dt float32) {
+	changed := false
+	for _, t := range m.animated {
+		before := t.player.Frame()
+		t.player.Update(dt)
+		changed = changed || t.player.Frame() != before
+	}
+	if !changed {
+		return
+	}
+	for _, l := range m.Layers {
+		for i := range l.chunks {
+			if l.chunks[i].animated {
+				l.chunks[i].dirty = true
+			}
+		}
+	}
+}
+
+// Tile returns the GID (with flip flags) at tile (x, y), 0 outside the layer.
+func (l *Layer) Tile(x, y int) uint32 {
+	x, y = x-l.X, y-l.Y
+	if l.Kind != TileLayer || x < 0 || y < 0 || x >= l.W || y >= l.H {
+		return 0
+	}
+	return l.gids[y*l.W+x]
+}
+
+// SetTile sets tile (x, y) to gid (0 clears it); its chunk is rebuilt when
+// next drawn. Tiles outside the layer are ignored.
+func (l *Layer) SetTile(x, y int, gid uint32) {
+	x, y = x-l.X, y-l.Y
+	if l.Kind != TileLayer || x < 0 || y < 0 || x >= l.W || y >= l.H {
+		return
+	}
+	l.gids[y*l.W+x] = gid
+	l.chunks[(y/chunkSize)*l.chunkCols+x/chunkSize].dirty = true
+}
+
+// -------- Properties --------
+
+// Properties are Tiled custom properties. Values are string (also color
+// and file), int (also object), float64, bool or Properties (class).
+type Properties map[string]any
+
+// String returns the string property name, or "".
+func (p Properties) String(name string) string {
+	s, _ := p[name].(string)
+	return s
+}
+
+// Int returns the int property name, or 0.
+func (p Properties) Int(name string) int {
+	switch v := p[name].(type) {
+	case int:
+		return v
+	case float64:
+		return int(v)
+	}
+	return 0
+}
+
+// Float returns the numeric property name, or 0.
+func (p Properties) Float(name string) float32 {
+	switch v := p[name].(type) {
+	case int:
+		return float32(v)
+	case float64:
+		return float32(v)
+	}
+	return 0
+}
+
+// Bool returns the bool property name, or false.
+func (p Properties) Bool(name string) bool {
+	b, _ := p[name].(bool)
+	return b
+}
@@ -0,0 +1,261 @@
+package tilemap
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/hubastard/grove/engine/gfx/renderer2d"
+)
+
+// The same 3x2 map in both formats: an inline tileset with an animated tile,
+// an external tileset of tall tiles from GID 9, a tile layer holding tiles
+// of both (one flipped), and an object layer inside an offset, half
+// transparent group.
+const testTMJ = `{
+	"orientation": "orthogonal", "width": 3, "height": 2, "tilewidth": 16, "tileheight": 16,
+	"properties": [{"name": "music", "type": "string", "value": "town.ogg"}],
+	"tilesets": [
+		{"firstgid": 1, "name": "ground", "tilewidth": 16, "tileheight": 16, "columns": 4, "tilecount": 8, "image": "ground.png",
+			"tiles": [{"id": 2, "class": "water",
+				"properties": [{"name": "speed", "type": "float", "value": 0.5}],
+				"animation": [{"tileid": 2, "duration": 100}, {"tileid": 3, "duration": 200}]}]},
+		{"firstgid": 9, "source": "props.tsj"}
+	],
+	"layers": [
+		{"type": "tilelayer", "name": "ground", "visible": true, "opacity": 1, "width": 3, "height": 2,
+			"data": [1, 2, 3, 9, 2147483658, 0]},
+		{"type": "group", "name": "top", "visible": true, "opacity": 0.5, "offsetx": 4, "layers": [
+			{"type": "objectgroup", "name": "things", "visible": true, "opacity": 1, "objects": [
+				{"id": 1, "name": "door", "x": 16, "y": 32, "width": 16, "height": 16, "gid": 10, "visible": true,
+					"properties": [{"name": "to", "type": "int", "value": 7}]}
+			]}
+		]}
+	]
+}`
+
+const testTSJ = `{"name": "props", "tilewidth": 16, "tileheight": 32, "columns": 2, "tilecount": 4,
+	"image": "props.png", "tileoffset": {"x": 0, "y": 2}}`
+
+const testTMX = `<?xml version="1.0" encoding="UTF-8"?>
+<map version="1.10" orientation="orthogonal" width="3" height="2" tilewidth="16" tileheight="16">
+ <properties>
+  <property name="music" value="town.ogg"/>
+ </properties>
+ <tileset firstgid="1" name="ground" tilewidth="16" tileheight="16" tilecount="8" columns="4">
+  <image source="ground.png" width="64" height="32"/>
+  <tile id="2" class="water">
+   <properties>
+    <property name="speed" type="float" value="0.5"/>
+   </properties>
+   <animation>
+    <frame tileid="2" duration="100"/>
+    <frame tileid="3" duration="200"/>
+   </animation>
+  </tile>
+ </tileset>
+ <tileset firstgid="9" source="props.tsx"/>
+ <layer id="1" name="ground" width="3" height="2">
+  <data encoding="csv">
+1,2,3,
+9,2147483658,0
+</data>
+ </layer>
+ <group id="2" name="top" opacity="0.5" offsetx="4">
+  <objectgroup id="3" name="things">
+   <object id="1" name="door" gid="10" x="16" y="32" width="16" height="16">
+    <properties>
+     <property name="to" type="int" value="7"/>
+    </properties>
+   </object>
+  </objectgroup>
+ </group>
+</map>`
+
+const testTSX = `<?xml version="1.0" encoding="UTF-8"?>
+<tileset name="props" tilewidth="16" tileheight="32" tilecount="4" columns="2">
+ <tileoffset x="0" y="2"/>
+ <image source="props.png" width="32" height="64"/>
+</tileset>`
+
+// loadTestMap writes the map and its tileset to a temporary directory and
+// parses the map (without uploading the images).
+func loadTestMap(t *testing.T, name string) (*Map, string) {
+	t.Helper()
+	dir := t.TempDir()
+	for file, src := range map[string]string{"map.tmj": testTMJ, "props.tsj": testTSJ, "map.tmx": testTMX, "props.tsx": testTSX} {
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	load := loadTMJ
+	if filepath.Ext(name) == ".tmx" {
+		load = loadTMX
+	}
+	m, err := load(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m, dir
+}
+
+func TestLoad(t *testing.T) {
+	for _, name := range []string{"map.tmj", "map.tmx"} {
+		t.Run(name, func(t *testing.T) {
+			m, dir := loadTestMap(t, name)
+			if m.Width != 3 || m.Height != 2 || m.TileW != 16 || m.TileH != 16 {
+				t.Errorf("map %dx%d of %dx%d tiles", m.Width, m.Height, m.TileW, m.TileH)
+			}
+			if got := m.Properties.String("music"); got != "town.ogg" {
+				t.Errorf("music = %q", got)
+			}
+
+			if len(m.Tilesets) != 2 {
+				t.Fatalf("%d tilesets", len(m.Tilesets))
+			}
+			ground, props := m.Tilesets[0], m.Tilesets[1]
+			if ground.Name != "ground" || ground.FirstGID != 1 || ground.Columns != 4 || ground.Count != 8 ||
+				ground.Image != filepath.Join(dir, "ground.png") {
+				t.Errorf("ground tileset %+v", ground)
+			}
+			if props.Name != "props" || props.FirstGID != 9 || props.TileH != 32 || props.OffsetY != 2 ||
+				props.Image != filepath.Join(dir, "props.png") {
+				t.Errorf("props tileset %+v", props)
+			}
+			water := ground.Tiles[2]
+			if water == nil || water.Class != "water" || water.Properties.Float("speed") != 0.5 {
+				t.Fatalf("water tile %+v", water)
+			}
+			if a := water.Animation; a == nil || len(a.Frames) != 2 || a.Frames[0].Duration != 0.1 || a.Frames[1].Duration != 0.2 ||
+				!slices.Equal(water.frameIDs, []int{2, 3}) {
+				t.Errorf("water animation %+v, frames %v", water.Animation, water.frameIDs)
+			}
+
+			// the group is flattened into its child
+			if len(m.Layers) != 2 {
+				t.Fatalf("%d layers", len(m.Layers))
+			}
+			tiles := m.Layer("ground")
+			if tiles == nil || tiles.Kind != TileLayer || !tiles.Visible || tiles.Opacity != 1 || tiles.W != 3 || tiles.H != 2 {
+				t.Fatalf("ground layer %+v", tiles)
+			}
+			want := []uint32{1, 2, 3, 9, FlipH | 10, 0}
+			for i, gid := range want {
+				if got := tiles.Tile(i%3, i/3); got != gid {
+					t.Errorf("tile (%d, %d) = %#x, want %#x", i%3, i/3, got, gid)
+				}
+			}
+			if got := tiles.Tile(3, 0); got != 0 {
+				t.Errorf("tile outside the layer = %d", got)
+			}
+
+			things := m.Layer("things")
+			if things == nil || things.Kind != ObjectLayer || !things.Visible || things.Opacity != 0.5 || things.OffsetX != 4 {
+				t.Fatalf("things layer %+v", things)
+			}
+			door := m.Object("door")
+			if door == nil || door.ID != 1 || door.GID != 10 || !door.Visible ||
+				door.X != 16 || door.Y != 32 || door.W != 16 || door.H != 16 || door.Properties.Int("to") != 7 {
+				t.Errorf("door %+v", door)
+			}
+		})
+	}
+}
+
+func TestTilesetGID(t *testing.T) {
+	m, _ := loadTestMap(t, "map.tmj")
+	ground, props := m.Tilesets[0], m.Tilesets[1]
+	tests := []struct {
+		gid uint32
+		ts  *Tileset
+		id  int
+	}{
+		{0, nil, 0},
+		{1, ground, 0},
+		{8, ground, 7},
+		{9, props, 0},
+		{12, props, 3},
+		{13, nil, 0}, // past the 4 tiles of props
+		{FlipH | FlipV | FlipD | 3, ground, 2},
+		{FlipH | 10, props, 1},
+		{FlipD, nil, 0},
+	}
+	for _, tc := range tests {
+		ts, id := m.Tileset(tc.gid)
+		if ts != tc.ts || id != tc.id {
+			t.Errorf("Tileset(%#x) = %v, %d; want %v, %d", tc.gid, ts, id, tc.ts, tc.id)
+		}
+	}
+	if tile := m.TileData(FlipV | 3); tile == nil || tile.Class != "water" {
+		t.Errorf("TileData of a flipped water tile = %+v", tile)
+	}
+}
+
+func TestTileQuadFlips(t *testing.T) {
+	// UVs of the corners TL, TR, BL, BR
+	tests := []struct {
+		name  string
+		flags uint32
+		uvs   [4][2]float32
+	}{
+		{"none", 0, [4][2]float32{{0, 0}, {1, 0}, {0, 1}, {1, 1}}},
+		{"horizontal", FlipH, [4][2]float32{{1, 0}, {0, 0}, {1, 1}, {0, 1}}},
+		{"vertical", FlipV, [4][2]float32{{0, 1}, {1, 1}, {0, 0}, {1, 0}}},
+		{"diagonal", FlipD, [4][2]float32{{0, 0}, {0, 1}, {1, 0}, {1, 1}}},
+		{"half turn", FlipH | FlipV, [4][2]float32{{1, 1}, {0, 1}, {1, 0}, {0, 0}}},
+		{"quarter turn clockwise", FlipD | FlipH, [4][2]float32{{0, 1}, {0, 0}, {1, 1}, {1, 0}}},
+		{"quarter turn counter-clockwise", FlipD | FlipV, [4][2]float32{{1, 0}, {1, 1}, {0, 0}, {0, 1}}},
+	}
+	sub := renderer2d.SubTexture2D{U1: 1, V1: 1}
+	pos := [4][2]float32{{10, 20}, {26, 20}, {10, 36}, {26, 36}}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			q := tileQuad(sub, tc.flags|5, [4]uint8{255, 255, 255, 255}, 10, 20, 26, 36)
+			for i, v := range q {
+				if [2]float32{v.X, v.Y} != pos[i] || [2]float32{v.U, v.V} != tc.uvs[i] {
+					t.Errorf("corner %d at (%g, %g) uv (%g, %g), want (%g, %g) uv (%g, %g)",
+						i, v.X, v.Y, v.U, v.V, pos[i][0], pos[i][1], tc.uvs[i][0], tc.uvs[i][1])
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeData(t *testing.T) {
+	gids := []uint32{1, FlipH | 2, 0, FlipD | FlipV | 300}
+	raw := make([]byte, 4*len(gids))
+	for i, g := range gids {
+		binary.LittleEndian.PutUint32(raw[4*i:], g)
+	}
+	var zl, gz bytes.Buffer
+	zw := zlib.NewWriter(&zl)
+	zw.Write(raw)
+	zw.Close()
+	gw := gzip.NewWriter(&gz)
+	gw.Write(raw)
+	gw.Close()
+
+	tests := []struct {
+		encoding, compression, text string
+	}{
+		{"csv", "", "1,2147483650,\n0,1610613036\n"},
+		{"base64", "", base64.StdEncoding.EncodeToString(raw)},
+		{"base64", "zlib", "\n   " + base64.StdEncoding.EncodeToString(zl.Bytes()) + "\n"},
+		{"base64", "gzip", base64.StdEncoding.EncodeToString(gz.Bytes())},
+	}
+	for _, tc := range tests {
+		got, err := decodeData(tc.encoding, tc.compression, tc.text)
+		if err != nil || !slices.Equal(got, gids) {
+			t.Errorf("%s %s: %v, %v; want %v", tc.encoding, tc.compression, got, err, gids)
+		}
+	}
+	if _, err := decodeData("base64", "zstd", ""); err == nil {
+		t.Error("zstd data decoded")
+	}
+}
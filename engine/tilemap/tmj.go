@@ -0,0 +1,305 @@
+package tilemap
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// -------- .tmj / .tsj (JSON) --------
+
+type jsonProperty struct {
+	Name  string
+	Type  string
+	Value json.RawMessage
+}
+
+type jsonTileset struct {
+	FirstGID   uint32
+	Source     string
+	Name       string
+	TileWidth  int
+	TileHeight int
+	Columns    int
+	TileCount  int
+	Margin     int
+	Spacing    int
+	Image      string
+	TileOffset struct{ X, Y float32 }
+	Properties []jsonProperty
+	Tiles      []struct {
+		ID         int
+		Type       string // class before Tiled 1.9
+		Class      string
+		Properties []jsonProperty
+		Animation  []struct{ TileID, Duration int }
+	}
+}
+
+type jsonData struct {
+	Data   json.RawMessage
+	X, Y   int
+	Width  int
+	Height int
+}
+
+type jsonLayer struct {
+	Type             string
+	Name             string
+	Class            string
+	Visible          bool
+	Opacity          float32
+	OffsetX, OffsetY float32
+	Properties       []jsonProperty
+
+	jsonData    // finite tile layers
+	Encoding    string
+	Compression string
+	Chunks      []jsonData // infinite tile layers
+
+	Objects []struct {
+		ID                  int
+		Name, Type, Class   string
+		X, Y, Width, Height float32
+		Rotation            float32
+		GID                 uint32
+		Visible             bool
+		Point, Ellipse      bool
+		Polygon, Polyline   []struct{ X, Y float32 }
+		Template            string
+		Properties          []jsonProperty
+	}
+
+	Layers []jsonLayer // group layers
+}
+
+type jsonMap struct {
+	Orientation string
+	Width       int
+	Height      int
+	TileWidth   int
+	TileHeight  int
+	Properties  []jsonProperty
+	Tilesets    []jsonTileset
+	Layers      []jsonLayer
+}
+
+func loadTMJ(path string) (*Map, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var jm jsonMap
+	if err := json.Unmarshal(data, &jm); err != nil {
+		return nil, err
+	}
+	if jm.Orientation != "orthogonal" {
+		return nil, fmt.Errorf("%s maps are not supported", jm.Orientation)
+	}
+
+	m := &Map{Width: jm.Width, Height: jm.Height, TileW: jm.TileWidth, TileH: jm.TileHeight}
+	if m.Properties, err = jsonProperties(jm.Properties); err != nil {
+		return nil, err
+	}
+	dir := filepath.Dir(path)
+	for _, jt := range jm.Tilesets {
+		var ts *Tileset
+		if jt.Source != "" {
+			ts, err = loadExternalTileset(dir, jt.Source, jt.FirstGID)
+		} else {
+			ts, err = jt.tileset(dir)
+			if ts != nil {
+				ts.FirstGID = jt.FirstGID
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		m.Tilesets = append(m.Tilesets, ts)
+	}
+	if err := m.addJSONLayers(jm.Layers, nil); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func loadTSJ(path string) (*Tileset, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var jt jsonTileset
+	if err := json.Unmarshal(data, &jt); err != nil {
+		return nil, err
+	}
+	return jt.tileset(filepath.Dir(path))
+}
+
+// tileset converts jt; its image path is relative to dir.
+func (jt *jsonTileset) tileset(dir string) (*Tileset, error) {
+	ts := &Tileset{
+		Name:  jt.Name,
+		TileW: jt.TileWidth, TileH: jt.TileHeight,
+		Columns: jt.Columns, Count: jt.TileCount,
+		Margin: jt.Margin, Spacing: jt.Spacing,
+		OffsetX: jt.TileOffset.X, OffsetY: jt.TileOffset.Y,
+		Tiles: make(map[int]*Tile, len(jt.Tiles)),
+	}
+	if jt.Image != "" {
+		ts.Image = filepath.Join(dir, jt.Image)
+	}
+	var err error
+	if ts.Properties, err = jsonProperties(jt.Properties); err != nil {
+		return nil, fmt.Errorf("tileset %q: %w", ts.Name, err)
+	}
+	for _, jtile := range jt.Tiles {
+		t := &Tile{ID: jtile.ID, Class: cmp.Or(jtile.Class, jtile.Type)}
+		if t.Properties, err = jsonProperties(jtile.Properties); err != nil {
+			return nil, fmt.Errorf("tileset %q: tile %d: %w", ts.Name, t.ID, err)
+		}
+		if len(jtile.Animation) > 0 {
+			ids, durations := make([]int, len(jtile.Animation)), make([]int, len(jtile.Animation))
+			for i, f := range jtile.Animation {
+				ids[i], durations[i] = f.TileID, f.Duration
+			}
+			tileAnimation(t, ids, durations)
+		}
+		ts.Tiles[t.ID] = t
+	}
+	return ts, nil
+}
+
+// addJSONLayers appends the layers, flattening groups into their children.
+func (m *Map) addJSONLayers(layers []jsonLayer, parent *Layer) error {
+	for _, jl := range layers {
+		l := &Layer{
+			Name: jl.Name, Class: jl.Class,
+			Visible: jl.Visible, Opacity: jl.Opacity,
+			OffsetX: jl.OffsetX, OffsetY: jl.OffsetY,
+		}
+		var err error
+		if l.Properties, err = jsonProperties(jl.Properties); err != nil {
+			return fmt.Errorf("layer %q: %w", jl.Name, err)
+		}
+		inherit(l, parent)
+
+		switch jl.Type {
+		case "group":
+			if err := m.addJSONLayers(jl.Layers, l); err != nil {
+				return err
+			}
+			continue
+		case "tilelayer":
+			l.Kind = TileLayer
+			chunks := jl.Chunks
+			if len(chunks) == 0 {
+				chunks = []jsonData{jl.jsonData}
+			}
+			tiles := make([]dataChunk, len(chunks))
+			for i, c := range chunks {
+				gids, err := jsonTiles(c.Data, jl.Encoding, jl.Compression)
+				if err != nil {
+					return fmt.Errorf("layer %q: %w", jl.Name, err)
+				}
+				tiles[i] = dataChunk{x: c.X, y: c.Y, w: c.Width, h: c.Height, gids: gids}
+			}
+			if err := l.setTiles(tiles); err != nil {
+				return err
+			}
+		case "objectgroup":
+			l.Kind = ObjectLayer
+			for _, jo := range jl.Objects {
+				if jo.Template != "" {
+					return fmt.Errorf("layer %q: object %d: templates are not supported", jl.Name, jo.ID)
+				}
+				o := Object{
+					ID: jo.ID, Name: jo.Name, Class: cmp.Or(jo.Class, jo.Type),
+					X: jo.X, Y: jo.Y, W: jo.Width, H: jo.Height,
+					Rotation: jo.Rotation, GID: jo.GID, Visible: jo.Visible,
+					Point: jo.Point, Ellipse: jo.Ellipse,
+				}
+				for _, p := range jo.Polygon {
+					o.Polygon = append(o.Polygon, [2]float32{p.X, p.Y})
+				}
+				for _, p := range jo.Polyline {
+					o.Polyline = append(o.Polyline, [2]float32{p.X, p.Y})
+				}
+				if o.Properties, err = jsonProperties(jo.Properties); err != nil {
+					return fmt.Errorf("layer %q: object %d: %w", jl.Name, jo.ID, err)
+				}
+				l.Objects = append(l.Objects, o)
+			}
+		default: // image layers
+			continue
+		}
+		m.Layers = append(m.Layers, l)
+	}
+	return nil
+}
+
+// inherit applies the visibility, opacity and offset of a group layer.
+func inherit(l, parent *Layer) {
+	if parent == nil {
+		return
+	}
+	l.Visible = l.Visible && parent.Visible
+	l.Opacity *= parent.Opacity
+	l.OffsetX += parent.OffsetX
+	l.OffsetY += parent.OffsetY
+}
+
+// jsonTiles decodes tile data given as an array or an encoded string.
+func jsonTiles(data json.RawMessage, encoding, compression string) ([]uint32, error) {
+	if encoding == "" || encoding == "csv" {
+		var gids []uint32
+		if err := json.Unmarshal(data, &gids); err != nil {
+			return nil, fmt.Errorf("tile data: %w", err)
+		}
+		return gids, nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("tile data: %w", err)
+	}
+	return decodeData(encoding, compression, s)
+}
+
+func jsonProperties(props []jsonProperty) (Properties, error) {
+	if len(props) == 0 {
+		return nil, nil
+	}
+	out := make(Properties, len(props))
+	for _, p := range props {
+		var v any
+		var err error
+		switch p.Type {
+		case "int", "object":
+			var i int
+			err = json.Unmarshal(p.Value, &i)
+			v = i
+		case "float":
+			var f float64
+			err = json.Unmarshal(p.Value, &f)
+			v = f
+		case "bool":
+			var b bool
+			err = json.Unmarshal(p.Value, &b)
+			v = b
+		case "class":
+			var members map[string]any
+			err = json.Unmarshal(p.Value, &members)
+			v = Properties(members)
+		default: // string, color, file
+			var s string
+			err = json.Unmarshal(p.Value, &s)
+			v = s
+		}
+		if err != nil {
+			return nil, fmt.Errorf("property %q: %w", p.Name, err)
+		}
+		out[p.Name] = v
+	}
+	return out, nil
+}
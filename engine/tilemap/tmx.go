@@ -0,0 +1,326 @@
+package tilemap
+
+import (
+	"cmp"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// -------- .tmx / .tsx (XML) --------
+
+type xmlProperty struct {
+	Name       string        `xml:"name,attr"`
+	Type       string        `xml:"type,attr"`
+	Value      *string       `xml:"value,attr"`
+	Text       string        `xml:",chardata"`           // multi-line strings
+	Properties []xmlProperty `xml:"properties>property"` // class members
+}
+
+type xmlTileset struct {
+	FirstGID   uint32        `xml:"firstgid,attr"`
+	Source     string        `xml:"source,attr"`
+	Name       string        `xml:"name,attr"`
+	TileWidth  int           `xml:"tilewidth,attr"`
+	TileHeight int           `xml:"tileheight,attr"`
+	Columns    int           `xml:"columns,attr"`
+	TileCount  int           `xml:"tilecount,attr"`
+	Margin     int           `xml:"margin,attr"`
+	Spacing    int           `xml:"spacing,attr"`
+	Properties []xmlProperty `xml:"properties>property"`
+	TileOffset struct {
+		X float32 `xml:"x,attr"`
+		Y float32 `xml:"y,attr"`
+	} `xml:"tileoffset"`
+	Image struct {
+		Source string `xml:"source,attr"`
+	} `xml:"image"`
+	Tiles []struct {
+		ID         int           `xml:"id,attr"`
+		Type       string        `xml:"type,attr"` // class before Tiled 1.9
+		Class      string        `xml:"class,attr"`
+		Properties []xmlProperty `xml:"properties>property"`
+		Frames     []struct {
+			TileID   int `xml:"tileid,attr"`
+			Duration int `xml:"duration,attr"`
+		} `xml:"animation>frame"`
+	} `xml:"tile"`
+}
+
+type xmlTile struct {
+	GID uint32 `xml:"gid,attr"`
+}
+
+type xmlData struct {
+	Encoding    string    `xml:"encoding,attr"`
+	Compression string    `xml:"compression,attr"`
+	Text        string    `xml:",chardata"`
+	Tiles       []xmlTile `xml:"tile"` // unencoded data
+	Chunks      []struct {
+		X      int       `xml:"x,attr"`
+		Y      int       `xml:"y,attr"`
+		Width  int       `xml:"width,attr"`
+		Height int       `xml:"height,attr"`
+		Text   string    `xml:",chardata"`
+		Tiles  []xmlTile `xml:"tile"`
+	} `xml:"chunk"`
+}
+
+// xmlLayer is any child of a map or group: layer, objectgroup, group or
+// imagelayer, kept in document order.
+type xmlLayer struct {
+	XMLName    xml.Name
+	Name       string        `xml:"name,attr"`
+	Class      string        `xml:"class,attr"`
+	Visible    *int          `xml:"visible,attr"`
+	Opacity    *float32      `xml:"opacity,attr"`
+	OffsetX    float32       `xml:"offsetx,attr"`
+	OffsetY    float32       `xml:"offsety,attr"`
+	Width      int           `xml:"width,attr"`
+	Height     int           `xml:"height,attr"`
+	Properties []xmlProperty `xml:"properties>property"`
+	Data       xmlData       `xml:"data"`
+	Objects    []struct {
+		ID         int           `xml:"id,attr"`
+		Name       string        `xml:"name,attr"`
+		Type       string        `xml:"type,attr"`
+		Class      string        `xml:"class,attr"`
+		X          float32       `xml:"x,attr"`
+		Y          float32       `xml:"y,attr"`
+		Width      float32       `xml:"width,attr"`
+		Height     float32       `xml:"height,attr"`
+		Rotation   float32       `xml:"rotation,attr"`
+		GID        uint32        `xml:"gid,attr"`
+		Visible    *int          `xml:"visible,attr"`
+		Template   string        `xml:"template,attr"`
+		Properties []xmlProperty `xml:"properties>property"`
+		Point      *struct{}     `xml:"point"`
+		Ellipse    *struct{}     `xml:"ellipse"`
+		Polygon    *struct {
+			Points string `xml:"points,attr"`
+		} `xml:"polygon"`
+		Polyline *struct {
+			Points string `xml:"points,attr"`
+		} `xml:"polyline"`
+	} `xml:"object"`
+	Layers []xmlLayer `xml:",any"` // group children
+}
+
+type xmlMap struct {
+	Orientation string        `xml:"orientation,attr"`
+	Width       int           `xml:"width,attr"`
+	Height      int           `xml:"height,attr"`
+	TileWidth   int           `xml:"tilewidth,attr"`
+	TileHeight  int           `xml:"tileheight,attr"`
+	Properties  []xmlProperty `xml:"properties>property"`
+	Tilesets    []xmlTileset  `xml:"tileset"`
+	Layers      []xmlLayer    `xml:",any"`
+}
+
+func loadTMX(path string) (*Map, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var xm xmlMap
+	if err := xml.Unmarshal(data, &xm); err != nil {
+		return nil, err
+	}
+	if xm.Orientation != "orthogonal" {
+		return nil, fmt.Errorf("%s maps are not supported", xm.Orientation)
+	}
+
+	m := &Map{Width: xm.Width, Height: xm.Height, TileW: xm.TileWidth, TileH: xm.TileHeight}
+	if m.Properties, err = xmlProperties(xm.Properties); err != nil {
+		return nil, err
+	}
+	dir := filepath.Dir(path)
+	for _, xt := range xm.Tilesets {
+		var ts *Tileset
+		if xt.Source != "" {
+			ts, err = loadExternalTileset(dir, xt.Source, xt.FirstGID)
+		} else {
+			ts, err = xt.tileset(dir)
+			if ts != nil {
+				ts.FirstGID = xt.FirstGID
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		m.Tilesets = append(m.Tilesets, ts)
+	}
+	if err := m.addXMLLayers(xm.Layers, nil); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func loadTSX(path string) (*Tileset, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var xt xmlTileset
+	if err := xml.Unmarshal(data, &xt); err != nil {
+		return nil, err
+	}
+	return xt.tileset(filepath.Dir(path))
+}
+
+// tileset converts xt; its image path is relative to dir.
+func (xt *xmlTileset) tileset(dir string) (*Tileset, error) {
+	ts := &Tileset{
+		Name:  xt.Name,
+		TileW: xt.TileWidth, TileH: xt.TileHeight,
+		Columns: xt.Columns, Count: xt.TileCount,
+		Margin: xt.Margin, Spacing: xt.Spacing,
+		OffsetX: xt.TileOffset.X, OffsetY: xt.TileOffset.Y,
+		Tiles: make(map[int]*Tile, len(xt.Tiles)),
+	}
+	if xt.Image.Source != "" {
+		ts.Image = filepath.Join(dir, xt.Image.Source)
+	}
+	var err error
+	if ts.Properties, err = xmlProperties(xt.Properties); err != nil {
+		return nil, fmt.Errorf("tileset %q: %w", ts.Name, err)
+	}
+	for _, xtile := range xt.Tiles {
+		t := &Tile{ID: xtile.ID, Class: cmp.Or(xtile.Class, xtile.Type)}
+		if t.Properties, err = xmlProperties(xtile.Properties); err != nil {
+			return nil, fmt.Errorf("tileset %q: tile %d: %w", ts.Name, t.ID, err)
+		}
+		if len(xtile.Frames) > 0 {
+			ids, durations := make([]int, len(xtile.Frames)), make([]int, len(xtile.Frames))
+			for i, f := range xtile.Frames {
+				ids[i], durations[i] = f.TileID, f.Duration
+			}
+			tileAnimation(t, ids, durations)
+		}
+		ts.Tiles[t.ID] = t
+	}
+	return ts, nil
+}
+
+// addXMLLayers appends the layers, flattening groups into their children.
+func (m *Map) addXMLLayers(layers []xmlLayer, parent *Layer) error {
+	for _, xl := range layers {
+		kind := xl.XMLName.Local
+		if kind != "layer" && kind != "objectgroup" && kind != "group" {
+			continue // image layers, editor settings
+		}
+		l := &Layer{
+			Name: xl.Name, Class: xl.Class,
+			Visible: xl.Visible == nil || *xl.Visible != 0, Opacity: 1,
+			OffsetX: xl.OffsetX, OffsetY: xl.OffsetY,
+		}
+		if xl.Opacity != nil {
+			l.Opacity = *xl.Opacity
+		}
+		var err error
+		if l.Properties, err = xmlProperties(xl.Properties); err != nil {
+			return fmt.Errorf("layer %q: %w", xl.Name, err)
+		}
+		inherit(l, parent)
+
+		switch kind {
+		case "group":
+			if err := m.addXMLLayers(xl.Layers, l); err != nil {
+				return err
+			}
+			continue
+		case "layer":
+			l.Kind = TileLayer
+			d := xl.Data
+			var tiles []dataChunk
+			if len(d.Chunks) == 0 {
+				gids, err := xmlTiles(d.Encoding, d.Compression, d.Text, d.Tiles)
+				if err != nil {
+					return fmt.Errorf("layer %q: %w", xl.Name, err)
+				}
+				tiles = []dataChunk{{w: xl.Width, h: xl.Height, gids: gids}}
+			}
+			for _, c := range d.Chunks {
+				gids, err := xmlTiles(d.Encoding, d.Compression, c.Text, c.Tiles)
+				if err != nil {
+					return fmt.Errorf("layer %q: %w", xl.Name, err)
+				}
+				tiles = append(tiles, dataChunk{x: c.X, y: c.Y, w: c.Width, h: c.Height, gids: gids})
+			}
+			if err := l.setTiles(tiles); err != nil {
+				return err
+			}
+		case "objectgroup":
+			l.Kind = ObjectLayer
+			for _, xo := range xl.Objects {
+				if xo.Template != "" {
+					return fmt.Errorf("layer %q: object %d: templates are not supported", xl.Name, xo.ID)
+				}
+				o := Object{
+					ID: xo.ID, Name: xo.Name, Class: cmp.Or(xo.Class, xo.Type),
+					X: xo.X, Y: xo.Y, W: xo.Width, H: xo.Height,
+					Rotation: xo.Rotation, GID: xo.GID,
+					Visible: xo.Visible == nil || *xo.Visible != 0,
+					Point:   xo.Point != nil, Ellipse: xo.Ellipse != nil,
+				}
+				if xo.Polygon != nil {
+					if o.Polygon, err = parsePoints(xo.Polygon.Points); err != nil {
+						return fmt.Errorf("layer %q: object %d: %w", xl.Name, xo.ID, err)
+					}
+				}
+				if xo.Polyline != nil {
+					if o.Polyline, err = parsePoints(xo.Polyline.Points); err != nil {
+						return fmt.Errorf("layer %q: object %d: %w", xl.Name, xo.ID, err)
+					}
+				}
+				if o.Properties, err = xmlProperties(xo.Properties); err != nil {
+					return fmt.Errorf("layer %q: object %d: %w", xl.Name, xo.ID, err)
+				}
+				l.Objects = append(l.Objects, o)
+			}
+		}
+		m.Layers = append(m.Layers, l)
+	}
+	return nil
+}
+
+// xmlTiles decodes encoded tile data, or the <tile> elements of
+// unencoded data.
+func xmlTiles(encoding, compression, text string, tiles []xmlTile) ([]uint32, error) {
+	if encoding != "" {
+		return decodeData(encoding, compression, text)
+	}
+	gids := make([]uint32, len(tiles))
+	for i, t := range tiles {
+		gids[i] = t.GID
+	}
+	return gids, nil
+}
+
+func xmlProperties(props []xmlProperty) (Properties, error) {
+	if len(props) == 0 {
+		return nil, nil
+	}
+	out := make(Properties, len(props))
+	for _, p := range props {
+		if p.Type == "class" {
+			members, err := xmlProperties(p.Properties)
+			if err != nil {
+				return nil, fmt.Errorf("property %q: %w", p.Name, err)
+			}
+			out[p.Name] = members
+			continue
+		}
+		value := p.Text
+		if p.Value != nil {
+			value = *p.Value
+		}
+		v, err := propertyValue(p.Type, value)
+		if err != nil {
+			return nil, fmt.Errorf("property %q: %w", p.Name, err)
+		}
+		out[p.Name] = v
+	}
+	return out, nil
+}
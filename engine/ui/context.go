@@ -5,12 +5,24 @@ package ui
 type Renderer interface {
 	// Draws a solid quad centered at (cx, cy) with w,h and color RGBA [0..1]
 	DrawQuad(cx, cy, w, h float32, color [4]float32, rotation float32)
+	// Draws a solid rectangle centered at (cx, cy) with rounded corners
+	DrawRoundedRect(cx, cy, w, h, radius float32, color [4]float32)
+	// Draws a nine-slice image into the rect with top-left (x, y), tinted
+	DrawImage(x, y, w, h float32, img Image, tint [4]float32)
 	// Draws text top-left at (x,y)
 	DrawText(x, y float32, text string, size float32, color [4]float32)
 	// Measures text (w,h) for a given font size
 	Measure(text string, size float32) (w, h float32)
 }
 
+// Image is a nine-slice background. Source is whatever the Renderer draws
+// from (e.g. a renderer2d.SubTexture2D); nil means no image.
+type Image struct {
+	Source any
+	Slice  Insets4 // border sizes in source pixels
+	Tile   bool    // tile edges and center instead of stretching them
+}
+
 type Input struct {
 	MouseX, MouseY float32
 	MouseDown      bool
@@ -51,6 +51,8 @@ type Props struct {
 	Gap        float32
 	Padding    Insets4
 	Bg         [4]float32 // optional background
+	Radius     float32    // background corner radius
+	BgImage    Image      // optional nine-slice background, tinted by Bg (white if unset)
 	// Optional fixed size override (if Sizing is Px)
 	// Otherwise used as constraints box when Expand
 	BoundsX float32
@@ -102,6 +104,8 @@ type cmd struct {
 	fontSize float32
 	color    [4]float32
 	bg       [4]float32
+	radius   float32
+	img      Image
 
 	// button interaction bookkeeping
 	hot, active bool
@@ -128,10 +132,12 @@ func BeginView(p Props) {
 		firstItem: len(ctx.items),
 		bgCmd:     -1,
 	}
-	if p.Bg[3] > 0 {
+	if p.Bg[3] > 0 || p.BgImage.Source != nil {
 		idx := emit(ctx, cmd{
-			kind: cmdBgQuad,
-			bg:   p.Bg,
+			kind:   cmdBgQuad,
+			bg:     p.Bg,
+			radius: p.Radius,
+			img:    p.BgImage,
 		})
 		if idx >= 0 {
 			scope.bgCmd = idx
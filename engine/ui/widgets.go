@@ -52,6 +52,8 @@ type ButtonProps struct {
 	FontSize float32
 	TextCol  [4]float32
 	Bg       [4]float32
+	Radius   float32 // background corner radius
+	BgImage  Image   // optional nine-slice background, tinted by Bg (white if unset)
 	Padding  Insets4
 	// Sizing modes: Fit (by default), Px, or Expand
 	Sizing *Sizing
@@ -85,6 +87,8 @@ func Button(p ButtonProps) (clicked bool) {
 		fontSize: p.FontSize,
 		color:    p.TextCol,
 		bg:       p.Bg,
+		radius:   p.Radius,
+		img:      p.BgImage,
 	})
 
 	addItem(ctx, item{kind: cmdButton, iCmd: iCmd, w: w, h: h})
@@ -119,9 +123,29 @@ func resolveWidget(ctx *Ctx, c *cmd) {
 }
 
 func drawQuad(ctx *Ctx, c *cmd) {
+	drawBg(ctx, c, imageTint(c))
+}
+
+// imageTint is the background color, white for an image without one.
+func imageTint(c *cmd) [4]float32 {
+	if c.img.Source != nil && c.bg == ([4]float32{}) {
+		return [4]float32{1, 1, 1, 1}
+	}
+	return c.bg
+}
+
+func drawBg(ctx *Ctx, c *cmd, bg [4]float32) {
+	if c.img.Source != nil {
+		ctx.R.DrawImage(c.x, c.y, c.w, c.h, c.img, bg)
+		return
+	}
 	cx := c.x + c.w*0.5
 	cy := c.y + c.h*0.5
-	ctx.R.DrawQuad(cx, cy, c.w, c.h, c.bg, 0)
+	if c.radius > 0 {
+		ctx.R.DrawRoundedRect(cx, cy, c.w, c.h, c.radius, bg)
+		return
+	}
+	ctx.R.DrawQuad(cx, cy, c.w, c.h, bg, 0)
 }
 
 func drawLabel(ctx *Ctx, c *cmd) {
@@ -149,7 +173,7 @@ func resolveButton(ctx *Ctx, c *cmd) {
 	ctx.state[c.id] = st // stable map mutation, no alloc after first insert
 
 	// draw bg (simple visual feedback)
-	bg := c.bg
+	bg := imageTint(c)
 	if st.active {
 		bg[0] *= 0.85
 		bg[1] *= 0.85
@@ -160,9 +184,7 @@ func resolveButton(ctx *Ctx, c *cmd) {
 		bg[2] *= 1.05
 	}
 	if bg[3] > 0 {
-		cx := c.x + c.w*0.5
-		cy := c.y + c.h*0.5
-		ctx.R.DrawQuad(cx, cy, c.w, c.h, bg, 0)
+		drawBg(ctx, c, bg)
 	}
 
 	// draw label centered inside